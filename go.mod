@@ -3,6 +3,11 @@ module github.com/babanini95/gatorcli
 go 1.24.1
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.32
+	golang.org/x/crypto v0.38.0
+	golang.org/x/term v0.32.0
 )
+
+require golang.org/x/sys v0.33.0 // indirect
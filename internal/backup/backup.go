@@ -0,0 +1,640 @@
+// Package backup copies a whole gator database to a portable archive and
+// back, so it can move between PostgreSQL and SQLite databases.
+//
+// An archive is newline delimited JSON. The first line is a header with the
+// format version, every other line is one row:
+//
+//	{"format":"gator-backup","version":1,"created_at":"2025-01-02T03:04:05Z"}
+//	{"type":"user","data":{"id":"...","name":"alice","role":"admin"}}
+//
+// Users come first, then sessions, feeds, feed retention, follows, posts
+// and post states, so every row only refers to rows above it. Sessions hold
+// token hashes, like users hold password hashes, so restored users stay
+// logged in.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	Format  = "gator-backup"
+	Version = 1
+)
+
+// postsPageSize is how many posts Write reads at a time.
+const postsPageSize = 1000
+
+// ErrNotEmpty is returned when restoring into a database that already has
+// data without merging.
+var ErrNotEmpty = errors.New("the database is not empty")
+
+type header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type line struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+const (
+	typeUser          = "user"
+	typeSession       = "session"
+	typeFeed          = "feed"
+	typeFeedRetention = "feed_retention"
+	typeFollow        = "follow"
+	typePost          = "post"
+	typePostState     = "post_state"
+)
+
+// tables lists the row types in archive order, with the table they are
+// counted under.
+var tables = []struct{ typ, table string }{
+	{typeUser, "users"},
+	{typeSession, "sessions"},
+	{typeFeed, "feeds"},
+	{typeFeedRetention, "feed_retention"},
+	{typeFollow, "feeds_follow"},
+	{typePost, "posts"},
+	{typePostState, "posts_state"},
+}
+
+// Count is the number of rows of a table that were written or restored.
+// Skipped rows were already in the database or refer to rows that aren't.
+type Count struct {
+	Table   string
+	Rows    int64
+	Skipped int64
+}
+
+func newCounts() ([]Count, map[string]*Count) {
+	counts := make([]Count, len(tables))
+	byType := map[string]*Count{}
+	for i, t := range tables {
+		counts[i].Table = t.table
+		byType[t.typ] = &counts[i]
+	}
+	return counts, byType
+}
+
+type user struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	Name         string     `json:"name"`
+	FeverKey     *string    `json:"fever_key,omitempty"`
+	PasswordHash *string    `json:"password_hash,omitempty"`
+	Role         string     `json:"role"`
+}
+
+type session struct {
+	TokenHash string     `json:"token_hash"`
+	UserID    uuid.UUID  `json:"user_id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+type feed struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	Name           *string    `json:"name,omitempty"`
+	URL            *string    `json:"url,omitempty"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	LastFetchedAt  *time.Time `json:"last_fetched_at,omitempty"`
+	LastFetchError *string    `json:"last_fetch_error,omitempty"`
+}
+
+type feedRetention struct {
+	FeedID      uuid.UUID `json:"feed_id"`
+	MaxAge      *int64    `json:"max_age,omitempty"`
+	MaxPosts    *int32    `json:"max_posts,omitempty"`
+	KeepUnread  *bool     `json:"keep_unread,omitempty"`
+	KeepStarred *bool     `json:"keep_starred,omitempty"`
+}
+
+type follow struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	FeedID    *uuid.UUID `json:"feed_id,omitempty"`
+	Folder    *string    `json:"folder,omitempty"`
+}
+
+type post struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	URL         *string    `json:"url,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	FeedID      *uuid.UUID `json:"feed_id,omitempty"`
+}
+
+type postState struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	StarredAt *time.Time `json:"starred_at,omitempty"`
+}
+
+// Write writes every row of db to w as an archive.
+func Write(ctx context.Context, db database.Store, w io.Writer) ([]Count, error) {
+	counts, byType := newCounts()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	write := func(typ string, data any) error {
+		byType[typ].Rows++
+		return enc.Encode(line{Type: typ, Data: data})
+	}
+
+	err := enc.Encode(header{Format: Format, Version: Version, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return counts, err
+	}
+
+	// Every table is read from the same snapshot, so rows written by agg or
+	// serve meanwhile can't leave the archive with posts of a missing feed
+	// or follows of a missing user.
+	err = db.InSnapshot(ctx, func(snapshot database.Store) error {
+		return dump(ctx, snapshot, write)
+	})
+	if err != nil {
+		return counts, err
+	}
+	return counts, bw.Flush()
+}
+
+// dump writes every row of db with write, in archive order.
+func dump(ctx context.Context, db database.Store, write func(typ string, data any) error) error {
+	users, err := db.ListAllUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read users: %v", err)
+	}
+	for _, u := range users {
+		err := write(typeUser, user{
+			ID:           u.ID,
+			CreatedAt:    timePtr(u.CreatedAt),
+			UpdatedAt:    timePtr(u.UpdatedAt),
+			Name:         u.Name,
+			FeverKey:     ptr(u.FeverKey.String, u.FeverKey.Valid),
+			PasswordHash: ptr(u.PasswordHash.String, u.PasswordHash.Valid),
+			Role:         u.Role,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sessions, err := db.ListAllSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read sessions: %v", err)
+	}
+	for _, se := range sessions {
+		err := write(typeSession, session{
+			TokenHash: se.TokenHash,
+			UserID:    se.UserID,
+			CreatedAt: timePtr(se.CreatedAt),
+			ExpiresAt: se.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	feeds, err := db.ListAllFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read feeds: %v", err)
+	}
+	for _, f := range feeds {
+		err := write(typeFeed, feed{
+			ID:             f.ID,
+			CreatedAt:      timePtr(f.CreatedAt),
+			UpdatedAt:      timePtr(f.UpdatedAt),
+			Name:           ptr(f.Name.String, f.Name.Valid),
+			URL:            ptr(f.Url.String, f.Url.Valid),
+			UserID:         ptr(f.UserID.UUID, f.UserID.Valid),
+			LastFetchedAt:  timePtr(f.LastFetchedAt),
+			LastFetchError: ptr(f.LastFetchError.String, f.LastFetchError.Valid),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	retention, err := db.ListAllFeedRetention(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read feed retention: %v", err)
+	}
+	for _, r := range retention {
+		err := write(typeFeedRetention, feedRetention{
+			FeedID:      r.FeedID,
+			MaxAge:      ptr(r.MaxAge.Int64, r.MaxAge.Valid),
+			MaxPosts:    ptr(r.MaxPosts.Int32, r.MaxPosts.Valid),
+			KeepUnread:  ptr(r.KeepUnread.Bool, r.KeepUnread.Valid),
+			KeepStarred: ptr(r.KeepStarred.Bool, r.KeepStarred.Valid),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	follows, err := db.ListAllFeedFollows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read follows: %v", err)
+	}
+	for _, ff := range follows {
+		err := write(typeFollow, follow{
+			ID:        ff.ID,
+			CreatedAt: timePtr(ff.CreatedAt),
+			UpdatedAt: timePtr(ff.UpdatedAt),
+			UserID:    ptr(ff.UserID.UUID, ff.UserID.Valid),
+			FeedID:    ptr(ff.FeedID.UUID, ff.FeedID.Valid),
+			Folder:    ptr(ff.Folder.String, ff.Folder.Valid),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Posts are read a page at a time, so a large database doesn't have to
+	// fit in memory.
+	var after int64
+	for {
+		posts, err := db.ListPostsAfter(ctx, database.ListPostsAfterParams{Seq: after, Limit: postsPageSize})
+		if err != nil {
+			return fmt.Errorf("failed to read posts: %v", err)
+		}
+		for _, p := range posts {
+			err := write(typePost, post{
+				ID:          p.ID,
+				CreatedAt:   timePtr(p.CreatedAt),
+				UpdatedAt:   timePtr(p.UpdatedAt),
+				Title:       ptr(p.Title.String, p.Title.Valid),
+				URL:         ptr(p.Url.String, p.Url.Valid),
+				Description: ptr(p.Description.String, p.Description.Valid),
+				PublishedAt: timePtr(p.PublishedAt),
+				FeedID:      ptr(p.FeedID.UUID, p.FeedID.Valid),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(posts) < postsPageSize {
+			break
+		}
+		after = posts[len(posts)-1].Seq
+	}
+
+	states, err := db.ListAllPostStates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read post states: %v", err)
+	}
+	for _, ps := range states {
+		err := write(typePostState, postState{
+			UserID:    ps.UserID,
+			PostID:    ps.PostID,
+			ReadAt:    timePtr(ps.ReadAt),
+			StarredAt: timePtr(ps.StarredAt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restorer restores the rows of an archive and remembers the ID each user,
+// feed and post got in the database, which differs from the one in the
+// archive when it was merged with a row that was already there.
+type restorer struct {
+	db    database.Store
+	users map[uuid.UUID]uuid.UUID
+	feeds map[uuid.UUID]uuid.UUID
+	posts map[uuid.UUID]uuid.UUID
+	// added holds the users that weren't in the database yet. Only they
+	// get their sessions back.
+	added map[uuid.UUID]bool
+}
+
+// Restore adds the rows of the archive in r to db, in a single
+// transaction. Unless merge is set the database has to be empty. When
+// merging, users with the same name, feeds with the same URL and posts with
+// the same URL are taken to be the same and the database keeps its own.
+func Restore(ctx context.Context, db database.Store, r io.Reader, merge bool) ([]Count, error) {
+	counts, byType := newCounts()
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var h header
+	if err := dec.Decode(&h); err != nil || h.Format != Format {
+		return counts, fmt.Errorf("not a gator backup")
+	}
+	if h.Version > Version {
+		return counts, fmt.Errorf("the backup has version %d, newer than the version %d this gator supports; upgrade gator", h.Version, Version)
+	}
+
+	err := db.InTx(ctx, func(tx database.Store) error {
+		if !merge {
+			rows, err := tx.CountRows(ctx)
+			if err != nil {
+				return err
+			}
+			if rows.Users+rows.Feeds+rows.Follows+rows.Posts > 0 {
+				return ErrNotEmpty
+			}
+		}
+
+		rs := restorer{
+			db:    tx,
+			users: map[uuid.UUID]uuid.UUID{},
+			feeds: map[uuid.UUID]uuid.UUID{},
+			posts: map[uuid.UUID]uuid.UUID{},
+			added: map[uuid.UUID]bool{},
+		}
+		for n := 2; ; n++ {
+			var rec record
+			if err := dec.Decode(&rec); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("line %d: %v", n, err)
+			}
+			count, ok := byType[rec.Type]
+			if !ok {
+				return fmt.Errorf("line %d: unknown row type %q", n, rec.Type)
+			}
+			restored, err := rs.restore(ctx, rec)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", n, err)
+			}
+			if restored {
+				count.Rows++
+			} else {
+				count.Skipped++
+			}
+		}
+	})
+	if err != nil {
+		for i := range counts {
+			counts[i].Rows, counts[i].Skipped = 0, 0
+		}
+	}
+	return counts, err
+}
+
+// restore restores a row and reports whether it was added.
+func (rs *restorer) restore(ctx context.Context, rec record) (bool, error) {
+	switch rec.Type {
+	case typeUser:
+		var u user
+		if err := json.Unmarshal(rec.Data, &u); err != nil {
+			return false, err
+		}
+		n, err := rs.db.RestoreUser(ctx, database.RestoreUserParams{
+			ID:           u.ID,
+			CreatedAt:    nullTime(u.CreatedAt),
+			UpdatedAt:    nullTime(u.UpdatedAt),
+			Name:         u.Name,
+			FeverKey:     nullString(u.FeverKey),
+			PasswordHash: nullString(u.PasswordHash),
+			Role:         u.Role,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to restore user %s: %v", u.Name, err)
+		}
+		if n > 0 {
+			rs.users[u.ID] = u.ID
+			rs.added[u.ID] = true
+			return true, nil
+		}
+		existing, err := rs.db.GetUser(ctx, u.Name)
+		if err != nil {
+			return false, fmt.Errorf("user %s conflicts with a different user in the database", u.Name)
+		}
+		rs.users[u.ID] = existing.ID
+		return false, nil
+
+	case typeSession:
+		var se session
+		if err := json.Unmarshal(rec.Data, &se); err != nil {
+			return false, err
+		}
+		if !rs.added[se.UserID] {
+			return false, nil
+		}
+		n, err := rs.db.RestoreSession(ctx, database.RestoreSessionParams{
+			TokenHash: se.TokenHash,
+			UserID:    se.UserID,
+			CreatedAt: nullTime(se.CreatedAt),
+			ExpiresAt: se.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to restore session: %v", err)
+		}
+		return n > 0, nil
+
+	case typeFeed:
+		var f feed
+		if err := json.Unmarshal(rec.Data, &f); err != nil {
+			return false, err
+		}
+		params := database.RestoreFeedParams{
+			ID:             f.ID,
+			CreatedAt:      nullTime(f.CreatedAt),
+			UpdatedAt:      nullTime(f.UpdatedAt),
+			Name:           nullString(f.Name),
+			Url:            nullString(f.URL),
+			LastFetchedAt:  nullTime(f.LastFetchedAt),
+			LastFetchError: nullString(f.LastFetchError),
+		}
+		if f.UserID != nil {
+			// A feed whose user is missing from the archive is kept
+			// without an owner.
+			id, ok := rs.users[*f.UserID]
+			params.UserID = uuid.NullUUID{UUID: id, Valid: ok}
+		}
+		n, err := rs.db.RestoreFeed(ctx, params)
+		if err != nil {
+			return false, fmt.Errorf("failed to restore feed %s: %v", params.Url.String, err)
+		}
+		if n > 0 || !params.Url.Valid {
+			// A feed without a URL can only conflict on its ID, so the
+			// database already has it under that ID.
+			rs.feeds[f.ID] = f.ID
+			return n > 0, nil
+		}
+		existing, err := rs.db.GetFeedByUrl(ctx, params.Url)
+		if err != nil {
+			return false, fmt.Errorf("feed %s conflicts with a different feed in the database", params.Url.String)
+		}
+		rs.feeds[f.ID] = existing.ID
+		return false, nil
+
+	case typeFeedRetention:
+		var r feedRetention
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return false, err
+		}
+		feedID, ok := rs.feeds[r.FeedID]
+		if !ok {
+			return false, nil
+		}
+		params := database.RestoreFeedRetentionParams{FeedID: feedID}
+		if r.MaxAge != nil {
+			params.MaxAge = sql.NullInt64{Int64: *r.MaxAge, Valid: true}
+		}
+		if r.MaxPosts != nil {
+			params.MaxPosts = sql.NullInt32{Int32: *r.MaxPosts, Valid: true}
+		}
+		params.KeepUnread = nullBool(r.KeepUnread)
+		params.KeepStarred = nullBool(r.KeepStarred)
+		n, err := rs.db.RestoreFeedRetention(ctx, params)
+		if err != nil {
+			return false, fmt.Errorf("failed to restore feed retention: %v", err)
+		}
+		return n > 0, nil
+
+	case typeFollow:
+		var ff follow
+		if err := json.Unmarshal(rec.Data, &ff); err != nil {
+			return false, err
+		}
+		userID, userOK := mapID(rs.users, ff.UserID)
+		feedID, feedOK := mapID(rs.feeds, ff.FeedID)
+		if !userOK || !feedOK {
+			return false, nil
+		}
+		n, err := rs.db.RestoreFeedFollow(ctx, database.RestoreFeedFollowParams{
+			ID:        ff.ID,
+			CreatedAt: nullTime(ff.CreatedAt),
+			UpdatedAt: nullTime(ff.UpdatedAt),
+			UserID:    userID,
+			FeedID:    feedID,
+			Folder:    nullString(ff.Folder),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to restore follow: %v", err)
+		}
+		return n > 0, nil
+
+	case typePost:
+		var p post
+		if err := json.Unmarshal(rec.Data, &p); err != nil {
+			return false, err
+		}
+		feedID, ok := mapID(rs.feeds, p.FeedID)
+		if !ok {
+			return false, nil
+		}
+		params := database.RestorePostParams{
+			ID:          p.ID,
+			CreatedAt:   nullTime(p.CreatedAt),
+			UpdatedAt:   nullTime(p.UpdatedAt),
+			Title:       nullString(p.Title),
+			Url:         nullString(p.URL),
+			Description: nullString(p.Description),
+			PublishedAt: nullTime(p.PublishedAt),
+			FeedID:      feedID,
+		}
+		n, err := rs.db.RestorePost(ctx, params)
+		if err != nil {
+			return false, fmt.Errorf("failed to restore post %s: %v", params.Url.String, err)
+		}
+		if n > 0 || !params.Url.Valid {
+			rs.posts[p.ID] = p.ID
+			return n > 0, nil
+		}
+		existing, err := rs.db.GetPostByUrl(ctx, params.Url)
+		if err != nil {
+			return false, fmt.Errorf("post %s conflicts with a different post in the database", params.Url.String)
+		}
+		rs.posts[p.ID] = existing.ID
+		return false, nil
+
+	case typePostState:
+		var ps postState
+		if err := json.Unmarshal(rec.Data, &ps); err != nil {
+			return false, err
+		}
+		userID, userOK := rs.users[ps.UserID]
+		postID, postOK := rs.posts[ps.PostID]
+		if !userOK || !postOK {
+			return false, nil
+		}
+		n, err := rs.db.RestorePostState(ctx, database.RestorePostStateParams{
+			UserID:    userID,
+			PostID:    postID,
+			ReadAt:    nullTime(ps.ReadAt),
+			StarredAt: nullTime(ps.StarredAt),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to restore post state: %v", err)
+		}
+		return n > 0, nil
+	}
+	return false, fmt.Errorf("unknown row type %q", rec.Type)
+}
+
+// mapID returns the database ID of an optional reference, and false when
+// it refers to a row that wasn't restored.
+func mapID(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) (uuid.NullUUID, bool) {
+	if id == nil {
+		return uuid.NullUUID{}, true
+	}
+	mapped, ok := ids[*id]
+	return uuid.NullUUID{UUID: mapped, Valid: ok}, ok
+}
+
+func ptr[T any](v T, valid bool) *T {
+	if !valid {
+		return nil
+	}
+	return &v
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	return ptr(t.Time, t.Valid)
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	// In UTC, so SQLite compares the text it stores like the times.
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
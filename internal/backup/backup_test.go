@@ -0,0 +1,442 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// testData is what seed adds to a store.
+type testData struct {
+	alice, bob database.User
+	blog, news database.Feed
+	posts      []database.Post
+}
+
+var seedTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func at(minutes int) sql.NullTime {
+	return sql.NullTime{Time: seedTime.Add(time.Duration(minutes) * time.Minute), Valid: true}
+}
+
+func text(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+func nullID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: true}
+}
+
+func createUser(t *testing.T, db database.Store, name string, minute int) database.User {
+	t.Helper()
+	user, err := db.CreateUser(context.Background(), database.CreateUserParams{
+		ID:           uuid.New(),
+		CreatedAt:    at(minute),
+		UpdatedAt:    at(minute),
+		Name:         name,
+		PasswordHash: text("hash of " + name),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createFeed(t *testing.T, db database.Store, user database.User, name, url string, minute int) database.Feed {
+	t.Helper()
+	feed, err := db.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: at(minute),
+		UpdatedAt: at(minute),
+		Name:      text(name),
+		Url:       text(url),
+		UserID:    nullID(user.ID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
+
+func createFollow(t *testing.T, db database.Store, user database.User, feed database.Feed, folder string, minute int) {
+	t.Helper()
+	_, err := db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: at(minute),
+		UpdatedAt: at(minute),
+		UserID:    nullID(user.ID),
+		FeedID:    nullID(feed.ID),
+		Folder:    sql.NullString{String: folder, Valid: folder != ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createPosts(t *testing.T, db database.Store, feed database.Feed, urlPrefix string, n int) {
+	t.Helper()
+	posts := []database.CreatePostParams{}
+	for i := range n {
+		posts = append(posts, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   at(100 + i),
+			UpdatedAt:   at(100 + i),
+			Title:       text(fmt.Sprintf("%s %d", feed.Name.String, i)),
+			Url:         text(fmt.Sprintf("%s/%d", urlPrefix, i)),
+			PublishedAt: at(i),
+			FeedID:      nullID(feed.ID),
+		})
+	}
+	if _, err := db.CreatePosts(context.Background(), posts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listPosts(t *testing.T, db database.Store) []database.Post {
+	t.Helper()
+	posts, err := db.ListPostsAfter(context.Background(), database.ListPostsAfterParams{Limit: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return posts
+}
+
+// seed adds two users with a session each, two feeds, follows, more posts
+// than fit in one page of Write, read and starred marks and a retention
+// setting.
+func seed(t *testing.T, db database.Store) testData {
+	t.Helper()
+	ctx := context.Background()
+	var d testData
+
+	d.alice = createUser(t, db, "alice", 0)
+	d.bob = createUser(t, db, "bob", 1)
+	for _, user := range []database.User{d.alice, d.bob} {
+		err := db.CreateSession(ctx, database.CreateSessionParams{
+			TokenHash: "token of " + user.Name,
+			UserID:    user.ID,
+			CreatedAt: at(2),
+			ExpiresAt: seedTime.Add(24 * time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d.blog = createFeed(t, db, d.alice, "Blog", "https://example.com/blog.xml", 3)
+	d.news = createFeed(t, db, d.bob, "News", "https://example.com/news.xml", 4)
+	createFollow(t, db, d.alice, d.blog, "", 5)
+	createFollow(t, db, d.alice, d.news, "Reading", 6)
+	createFollow(t, db, d.bob, d.news, "", 7)
+	err := db.SetFeedRetention(ctx, database.SetFeedRetentionParams{
+		FeedID:   d.news.ID,
+		MaxPosts: sql.NullInt32{Int32: 100, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createPosts(t, db, d.blog, "https://example.com/blog", 3)
+	createPosts(t, db, d.news, "https://example.com/news", postsPageSize+10)
+	d.posts = listPosts(t, db)
+
+	err = db.SetPostRead(ctx, database.SetPostReadParams{UserID: d.alice.ID, PostID: d.posts[0].ID, ReadAt: at(200)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.SetPostStarred(ctx, database.SetPostStarredParams{UserID: d.bob.ID, PostID: d.posts[len(d.posts)-1].ID, StarredAt: at(201)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func backup(t *testing.T, db database.Store) ([]byte, []Count) {
+	t.Helper()
+	var buf bytes.Buffer
+	counts, err := Write(context.Background(), db, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), counts
+}
+
+// rows returns the lines of an archive after the header.
+func rows(archive []byte) []string {
+	lines := strings.Split(strings.TrimSpace(string(archive)), "\n")
+	return lines[1:]
+}
+
+func countOf(counts []Count, table string) Count {
+	for _, c := range counts {
+		if c.Table == table {
+			return c
+		}
+	}
+	return Count{Table: table}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := database.NewMemoryStore()
+	d := seed(t, src)
+
+	archive, written := backup(t, src)
+	want := map[string]int64{
+		"users":          2,
+		"sessions":       2,
+		"feeds":          2,
+		"feed_retention": 1,
+		"feeds_follow":   3,
+		"posts":          int64(len(d.posts)),
+		"posts_state":    2,
+	}
+	for table, n := range want {
+		if got := countOf(written, table).Rows; got != n {
+			t.Errorf("wrote %d %s, want %d", got, table, n)
+		}
+	}
+
+	dst := database.NewMemoryStore()
+	restored, err := Restore(ctx, dst, bytes.NewReader(archive), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for table, n := range want {
+		if c := countOf(restored, table); c.Rows != n || c.Skipped != 0 {
+			t.Errorf("restored %d %s and skipped %d, want %d restored", c.Rows, table, c.Skipped, n)
+		}
+	}
+
+	// A backup of the restored database has the same rows.
+	again, _ := backup(t, dst)
+	got, exp := rows(again), rows(archive)
+	if len(got) != len(exp) {
+		t.Fatalf("the restored database backs up to %d rows, want %d", len(got), len(exp))
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("row %d differs after the round trip:\n got %s\nwant %s", i+2, got[i], exp[i])
+		}
+	}
+
+	// Restored users stay logged in.
+	user, err := dst.GetSessionUser(ctx, database.GetSessionUserParams{TokenHash: "token of bob", ExpiresAt: seedTime})
+	if err != nil || user.ID != d.bob.ID {
+		t.Errorf("bob's session was not restored: %v", err)
+	}
+}
+
+func TestRestoreNeedsEmptyDatabase(t *testing.T) {
+	src := database.NewMemoryStore()
+	seed(t, src)
+	archive, _ := backup(t, src)
+
+	dst := database.NewMemoryStore()
+	createUser(t, dst, "carol", 0)
+	_, err := Restore(context.Background(), dst, bytes.NewReader(archive), false)
+	if !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("restoring into a database in use: %v, want %v", err, ErrNotEmpty)
+	}
+	if users, _ := dst.ListAllUsers(context.Background()); len(users) != 1 {
+		t.Errorf("a refused restore left %d users", len(users))
+	}
+}
+
+func TestRestoreMerge(t *testing.T) {
+	ctx := context.Background()
+	src := database.NewMemoryStore()
+	d := seed(t, src)
+	archive, _ := backup(t, src)
+
+	// The target has its own alice, its own copy of the news feed and the
+	// first news post, all with different IDs than in the archive.
+	dst := database.NewMemoryStore()
+	alice := createUser(t, dst, "alice", 0)
+	news := createFeed(t, dst, alice, "News elsewhere", "https://example.com/news.xml", 0)
+	createPosts(t, dst, news, "https://example.com/news", 1)
+	existingPost := listPosts(t, dst)[0]
+
+	counts, err := Restore(ctx, dst, bytes.NewReader(archive), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for table, want := range map[string]Count{
+		"users":        {Rows: 1, Skipped: 1},
+		"sessions":     {Rows: 1, Skipped: 1},
+		"feeds":        {Rows: 1, Skipped: 1},
+		"feeds_follow": {Rows: 3},
+		"posts":        {Rows: int64(len(d.posts)) - 1, Skipped: 1},
+	} {
+		if c := countOf(counts, table); c.Rows != want.Rows || c.Skipped != want.Skipped {
+			t.Errorf("%s: restored %d and skipped %d, want %d and %d", table, c.Rows, c.Skipped, want.Rows, want.Skipped)
+		}
+	}
+
+	feeds, err := dst.ListAllFeeds(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feeds) != 2 {
+		t.Errorf("%d feeds after merging, want the news feed only once", len(feeds))
+	}
+	feedIDs := map[uuid.UUID]bool{}
+	for _, feed := range feeds {
+		feedIDs[feed.ID] = true
+	}
+	if feedIDs[d.news.ID] {
+		t.Error("the news feed of the archive was added next to the one with the same URL")
+	}
+
+	// Follows and posts of the merged feed point to the target's copy.
+	follows, err := dst.ListAllFeedFollows(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ff := range follows {
+		if !feedIDs[ff.FeedID.UUID] {
+			t.Errorf("follow %s points to missing feed %s", ff.ID, ff.FeedID.UUID)
+		}
+		if ff.UserID.UUID == d.alice.ID {
+			t.Errorf("follow %s points to the archive's alice instead of the one in the database", ff.ID)
+		}
+	}
+	posts := listPosts(t, dst)
+	if len(posts) != len(d.posts) {
+		t.Errorf("%d posts after merging, want %d", len(posts), len(d.posts))
+	}
+	for _, post := range posts {
+		if !feedIDs[post.FeedID.UUID] {
+			t.Errorf("post %s points to missing feed %s", post.Url.String, post.FeedID.UUID)
+		}
+	}
+
+	// alice's read mark on the first blog post came along; bob's star on
+	// the last news post did too.
+	states, err := dst.ListAllPostStates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("%d post states after merging, want 2", len(states))
+	}
+	for _, ps := range states {
+		if ps.UserID == d.alice.ID {
+			t.Error("a post state points to the archive's alice")
+		}
+		if ps.PostID == existingPost.ID {
+			t.Error("a post state moved to a post it wasn't on")
+		}
+	}
+
+	// Merging the same archive again adds nothing.
+	counts, err = Restore(ctx, dst, bytes.NewReader(archive), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range counts {
+		if c.Rows != 0 {
+			t.Errorf("merging twice restored %d %s", c.Rows, c.Table)
+		}
+	}
+}
+
+func TestRestoreMergeMapsStatesToExistingPosts(t *testing.T) {
+	ctx := context.Background()
+	src := database.NewMemoryStore()
+	d := seed(t, src)
+	archive, _ := backup(t, src)
+
+	// The target already has the blog post alice read, under another ID.
+	dst := database.NewMemoryStore()
+	carol := createUser(t, dst, "carol", 0)
+	blog := createFeed(t, dst, carol, "Blog", "https://example.com/blog.xml", 0)
+	createPosts(t, dst, blog, "https://example.com/blog", 1)
+	existing := listPosts(t, dst)[0]
+	if existing.Url != d.posts[0].Url {
+		t.Fatalf("the test expects %s to be the post alice read", existing.Url.String)
+	}
+
+	if _, err := Restore(ctx, dst, bytes.NewReader(archive), true); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := dst.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := dst.ListAllPostStates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(states, func(ps database.PostsState) bool {
+		return ps.UserID == alice.ID && ps.PostID == existing.ID && ps.ReadAt.Valid
+	}) {
+		t.Error("alice's read mark didn't move to the post already in the database")
+	}
+}
+
+func TestRestoreRejectsOtherFiles(t *testing.T) {
+	for _, archive := range []string{
+		"",
+		"not json\n",
+		`{"format":"something-else","version":1}` + "\n",
+		fmt.Sprintf(`{"format":%q,"version":%d}`+"\n", Format, Version+1),
+		fmt.Sprintf(`{"format":%q,"version":%d}`+"\n"+`{"type":"table","data":{}}`+"\n", Format, Version),
+	} {
+		_, err := Restore(context.Background(), database.NewMemoryStore(), strings.NewReader(archive), false)
+		if err == nil {
+			t.Errorf("restored %q", archive)
+		}
+	}
+}
+
+func TestRestoreFeedMapsOnlyResolvedIDs(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+	alice := createUser(t, db, "alice", 0)
+	existing := createFeed(t, db, alice, "Blog", "https://example.com/blog.xml", 0)
+
+	rs := restorer{
+		db:    db,
+		users: map[uuid.UUID]uuid.UUID{},
+		feeds: map[uuid.UUID]uuid.UUID{},
+		posts: map[uuid.UUID]uuid.UUID{},
+		added: map[uuid.UUID]bool{},
+	}
+	restoreFeed := func(f feed) (bool, error) {
+		data, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs.restore(ctx, record{Type: typeFeed, Data: data})
+	}
+
+	// Same URL: mapped to the feed in the database.
+	other := uuid.New()
+	url := "https://example.com/blog.xml"
+	if restored, err := restoreFeed(feed{ID: other, URL: &url}); err != nil || restored {
+		t.Fatalf("restoring a feed with a URL in use: restored %v, %v", restored, err)
+	}
+	if rs.feeds[other] != existing.ID {
+		t.Errorf("the feed is mapped to %s, want %s", rs.feeds[other], existing.ID)
+	}
+
+	// Same ID but another URL: there is no feed to map it to.
+	otherURL := "https://example.com/other.xml"
+	if _, err := restoreFeed(feed{ID: existing.ID, URL: &otherURL}); err == nil {
+		t.Error("restoring a feed whose ID belongs to another URL succeeded")
+	}
+	if id, ok := rs.feeds[existing.ID]; ok {
+		t.Errorf("the failed feed is mapped to %s", id)
+	}
+}
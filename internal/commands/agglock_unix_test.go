@@ -0,0 +1,40 @@
+//go:build unix
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggPIDFile(t *testing.T) {
+	s := newTestState(t)
+	pidFile := filepath.Join(t.TempDir(), "agg.pid")
+	mustRun(t, s, "", "register", "alice")
+
+	// The lock is held on its own open file, so it keeps a second agg in
+	// this process out just like one in another process.
+	unlock, err := lockAgg(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = runCommand(t, s, "", "agg", "--once", "--pid-file", pidFile)
+	if err == nil {
+		t.Fatal("a second agg with the same --pid-file started")
+	}
+	if want := fmt.Sprintf("agg is already running as pid %d", os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("the second agg failed with %q, want %q", err, want)
+	}
+
+	// Another PID file is another agg.
+	mustRun(t, s, "", "agg", "--once", "--pid-file", pidFile+".other")
+
+	unlock()
+	mustRun(t, s, "", "agg", "--once", "--pid-file", pidFile)
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("agg left its PID file behind: %v", err)
+	}
+}
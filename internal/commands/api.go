@@ -0,0 +1,475 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	apiUserHeader      = "X-Gator-User"
+	apiDefaultPageSize = 20
+	apiMaxPageSize     = 100
+)
+
+// apiServer exposes the database over a JSON API. Records use the same
+// field names as the --output formats.
+type apiServer struct {
+	s *state
+}
+
+// apiError is an error with the HTTP status it should be reported with.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e apiError) Error() string {
+	return e.msg
+}
+
+func errBadRequest(format string, a ...any) error {
+	return apiError{http.StatusBadRequest, fmt.Sprintf(format, a...)}
+}
+
+func errNotFound(format string, a ...any) error {
+	return apiError{http.StatusNotFound, fmt.Sprintf(format, a...)}
+}
+
+func (api *apiServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/users", api.handleUser(api.listUsers))
+	mux.HandleFunc("POST /api/users", api.handle(api.createUser))
+	mux.HandleFunc("POST /api/sessions", api.handle(api.createSession))
+	mux.HandleFunc("DELETE /api/sessions", api.handle(api.deleteSession))
+	mux.HandleFunc("GET /api/feeds", api.handle(api.listFeeds))
+	mux.HandleFunc("POST /api/feeds", api.handleUser(api.createFeed))
+	mux.HandleFunc("GET /api/follows", api.handleUser(api.listFollows))
+	mux.HandleFunc("POST /api/follows", api.handleUser(api.createFollow))
+	mux.HandleFunc("DELETE /api/follows", api.handleUser(api.deleteFollow))
+	mux.HandleFunc("GET /api/posts", api.handleUser(api.listPosts))
+	mux.HandleFunc("GET /api/posts/{id}", api.handleUser(api.getPost))
+	mux.HandleFunc("PUT /api/posts/{id}/read", api.handleUser(api.setRead(true)))
+	mux.HandleFunc("DELETE /api/posts/{id}/read", api.handleUser(api.setRead(false)))
+	mux.HandleFunc("PUT /api/posts/{id}/star", api.handleUser(api.setStarred(true)))
+	mux.HandleFunc("DELETE /api/posts/{id}/star", api.handleUser(api.setStarred(false)))
+	mux.HandleFunc("POST /api/fetch", api.handleUser(api.fetch))
+	mux.HandleFunc("GET /export/{user}/{format}", api.exportFeed)
+	mux.HandleFunc("/fever/", api.fever)
+	return mux
+}
+
+// handle turns a handler returning a response body into an http.HandlerFunc.
+// A nil body is sent as 204 No Content.
+func (api *apiServer) handle(h func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := h(r)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if body == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, body)
+	}
+}
+
+// handleUser is middlewareLoggedIn for the API: the user is given by a
+// session token in the Authorization header, or named by the X-Gator-User
+// header when they have no password.
+func (api *apiServer) handleUser(h func(r *http.Request, user database.User) (any, error)) http.HandlerFunc {
+	return api.handle(func(r *http.Request) (any, error) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			user, err := sessionUser(r.Context(), api.s, token)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, apiError{http.StatusUnauthorized, "invalid or expired session token"}
+			}
+			if err != nil {
+				return nil, err
+			}
+			return h(r, user)
+		}
+
+		name := r.Header.Get(apiUserHeader)
+		if name == "" {
+			return nil, apiError{http.StatusUnauthorized, apiUserHeader + " header is required"}
+		}
+
+		user, err := api.s.db.GetUser(r.Context(), name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apiError{http.StatusUnauthorized, "unknown user " + name}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.PasswordHash.Valid {
+			return nil, apiError{http.StatusUnauthorized, name + " has a password, use a session token"}
+		}
+		return h(r, user)
+	})
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr apiError
+	if errors.As(err, &apiErr) {
+		status = apiErr.status
+	} else if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusNotFound
+	}
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeAPIJSON encodes slices and structs of database rows as records and
+// anything else with encoding/json.
+func writeAPIJSON(w http.ResponseWriter, status int, body any) {
+	buf := bytes.Buffer{}
+	v := reflect.ValueOf(body)
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		renderJSON(&buf, toRecords(body))
+	case v.Kind() == reflect.Struct:
+		writeJSONObject(&buf, toRecord(v))
+		buf.WriteString("\n")
+	default:
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func decodeAPIBody(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errBadRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func (api *apiServer) listUsers(r *http.Request, user database.User) (any, error) {
+	if user.Role != roleAdmin {
+		return nil, apiError{http.StatusForbidden, "only admins can list users"}
+	}
+	users, err := api.s.db.GetUsers(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return userRecords(users), nil
+}
+
+func (api *apiServer) createUser(r *http.Request) (any, error) {
+	body := struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}{}
+	if err := decodeAPIBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.Name == "" {
+		return nil, errBadRequest("name is required")
+	}
+	if isUserExist(api.s, body.Name) {
+		return nil, apiError{http.StatusConflict, "user already exists"}
+	}
+	passwordHash, err := hashPassword(body.Password)
+	if err != nil {
+		return nil, errBadRequest("%v", err)
+	}
+
+	user, err := api.s.db.CreateUser(r.Context(), database.CreateUserParams{
+		ID:           uuid.New(),
+		CreatedAt:    sqlCurrentTime(),
+		UpdatedAt:    sqlCurrentTime(),
+		Name:         body.Name,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newUserRecord(user), nil
+}
+
+// createSession logs a user in and returns a token for the Authorization
+// header.
+func (api *apiServer) createSession(r *http.Request) (any, error) {
+	body := struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}{}
+	if err := decodeAPIBody(r, &body); err != nil {
+		return nil, err
+	}
+
+	user, err := api.s.db.GetUser(r.Context(), body.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apiError{http.StatusUnauthorized, errWrongPassword.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(user, body.Password); err != nil {
+		return nil, apiError{http.StatusUnauthorized, err.Error()}
+	}
+
+	token, err := createSession(r.Context(), api.s, user)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"token": token}, nil
+}
+
+func (api *apiServer) deleteSession(r *http.Request) (any, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, apiError{http.StatusUnauthorized, "Authorization header is required"}
+	}
+	return nil, api.s.db.DeleteSession(r.Context(), hashToken(token))
+}
+
+func (api *apiServer) listFeeds(r *http.Request) (any, error) {
+	feeds, err := api.s.db.ListFeeds(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+func (api *apiServer) createFeed(r *http.Request, user database.User) (any, error) {
+	body := struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}{}
+	if err := decodeAPIBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.Name == "" || body.Url == "" {
+		return nil, errBadRequest("name and url are required")
+	}
+
+	feed, err := api.s.db.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: sqlCurrentTime(),
+		UpdatedAt: sqlCurrentTime(),
+		Name:      sqlString(body.Name),
+		Url:       sqlString(body.Url),
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = api.s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: sqlCurrentTime(),
+		UpdatedAt: sqlCurrentTime(),
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID:    uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+func (api *apiServer) listFollows(r *http.Request, user database.User) (any, error) {
+	follows, err := api.s.db.GetFeedFollowsForUser(r.Context(), uuid.NullUUID{UUID: user.ID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return follows, nil
+}
+
+func (api *apiServer) createFollow(r *http.Request, user database.User) (any, error) {
+	body := struct {
+		Url    string `json:"url"`
+		Folder string `json:"folder"`
+	}{}
+	if err := decodeAPIBody(r, &body); err != nil {
+		return nil, err
+	}
+
+	feed, err := api.s.db.GetFeedByUrl(r.Context(), sqlString(body.Url))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound("no feed with url %q", body.Url)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return api.s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: sqlCurrentTime(),
+		UpdatedAt: sqlCurrentTime(),
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID:    uuid.NullUUID{UUID: feed.ID, Valid: true},
+		Folder:    sqlString(body.Folder),
+	})
+}
+
+func (api *apiServer) deleteFollow(r *http.Request, user database.User) (any, error) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		return nil, errBadRequest("url query parameter is required")
+	}
+
+	err := api.s.db.DeleteFeedFollowsByUrl(r.Context(), database.DeleteFeedFollowsByUrlParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Url:    sqlString(url),
+	})
+	return nil, err
+}
+
+// listPosts supports the feed_url, folder, q, unread and starred filters and
+// limit/offset pagination.
+func (api *apiServer) listPosts(r *http.Request, user database.User) (any, error) {
+	query := r.URL.Query()
+	params := database.ListPostsForUserParams{
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		Folder:    sqlString(query.Get("folder")),
+		PageLimit: apiDefaultPageSize,
+	}
+
+	if feedURL := query.Get("feed_url"); feedURL != "" {
+		feed, err := api.s.db.GetFeedByUrl(r.Context(), sqlString(feedURL))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errNotFound("no feed with url %q", feedURL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if q := query.Get("q"); q != "" {
+		params.Pattern = sqlString(containsPattern(q))
+	}
+
+	var err error
+	if params.Unread, err = queryBool(query.Get("unread")); err != nil {
+		return nil, errBadRequest("invalid unread: %v", err)
+	}
+	if params.Starred, err = queryBool(query.Get("starred")); err != nil {
+		return nil, errBadRequest("invalid starred: %v", err)
+	}
+	if params.PageLimit, err = queryInt(query.Get("limit"), apiDefaultPageSize); err != nil || params.PageLimit < 1 || params.PageLimit > apiMaxPageSize {
+		return nil, errBadRequest("limit must be between 1 and %d", apiMaxPageSize)
+	}
+	if params.PageOffset, err = queryInt(query.Get("offset"), 0); err != nil || params.PageOffset < 0 {
+		return nil, errBadRequest("offset must be a positive number")
+	}
+
+	posts, err := api.s.db.ListPostsForUser(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (api *apiServer) getPost(r *http.Request, user database.User) (any, error) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return nil, errBadRequest("invalid post id")
+	}
+
+	return api.s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		ID:     id,
+	})
+}
+
+func (api *apiServer) setRead(read bool) func(r *http.Request, user database.User) (any, error) {
+	return func(r *http.Request, user database.User) (any, error) {
+		post, err := api.getPost(r, user)
+		if err != nil {
+			return nil, err
+		}
+
+		readAt := sql.NullTime{}
+		if read {
+			readAt = sqlCurrentTime()
+		}
+		return nil, api.s.db.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID: user.ID,
+			PostID: post.(database.GetPostForUserRow).ID,
+			ReadAt: readAt,
+		})
+	}
+}
+
+func (api *apiServer) setStarred(starred bool) func(r *http.Request, user database.User) (any, error) {
+	return func(r *http.Request, user database.User) (any, error) {
+		post, err := api.getPost(r, user)
+		if err != nil {
+			return nil, err
+		}
+
+		starredAt := sql.NullTime{}
+		if starred {
+			starredAt = sqlCurrentTime()
+		}
+		return nil, api.s.db.SetPostStarred(r.Context(), database.SetPostStarredParams{
+			UserID:    user.ID,
+			PostID:    post.(database.GetPostForUserRow).ID,
+			StarredAt: starredAt,
+		})
+	}
+}
+
+// fetch scrapes the feed given by url, or the user's next feed to fetch,
+// and returns it.
+func (api *apiServer) fetch(r *http.Request, user database.User) (any, error) {
+	body := struct {
+		Url string `json:"url"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := decodeAPIBody(r, &body); err != nil {
+			return nil, err
+		}
+	}
+
+	var feed database.Feed
+	var err error
+	if body.Url != "" {
+		feed, err = api.s.db.GetFeedByUrl(r.Context(), sqlString(body.Url))
+	} else {
+		feed, err = api.s.db.GetNextFeedToFetch(r.Context(), uuid.NullUUID{UUID: user.ID, Valid: true})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound("no feed to fetch")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := scrapeFeed(r.Context(), api.s, feed); err != nil {
+		return nil, apiError{http.StatusBadGateway, fmt.Sprintf("failed to fetch %s: %v", feed.Url.String, err)}
+	}
+	return api.s.db.GetFeedByUrl(r.Context(), feed.Url)
+}
+
+func queryBool(value string) (sql.NullBool, error) {
+	if value == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(value)
+	return sql.NullBool{Bool: b, Valid: err == nil}, err
+}
+
+func queryInt(value string, fallback int32) (int32, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	return int32(n), err
+}
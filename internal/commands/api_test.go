@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// apiClient calls the API as the user given by its headers.
+type apiClient struct {
+	t      *testing.T
+	url    string
+	header http.Header
+}
+
+func newAPIServer(t *testing.T) (*state, *httptest.Server) {
+	t.Helper()
+	s := newTestState(t)
+	server := httptest.NewServer((&apiServer{s: s}).routes())
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// as returns a client sending the header name with value.
+func (c apiClient) as(name, value string) apiClient {
+	c.header = http.Header{}
+	c.header.Set(name, value)
+	return c
+}
+
+// do sends body as JSON and decodes the response into out, unless it is
+// nil, and returns the status.
+func (c apiClient) do(method, path string, body any, out any) int {
+	c.t.Helper()
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.url+path, reqBody)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			c.t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPIAuth(t *testing.T) {
+	s, server := newAPIServer(t)
+	mustRun(t, s, "", "register", "alice")
+	api := apiClient{t: t, url: server.URL}
+
+	if status := api.do("POST", "/api/users", map[string]string{"name": "bob", "password": "correct horse"}, nil); status != http.StatusOK {
+		t.Fatalf("creating bob returned %d", status)
+	}
+	if status := api.do("POST", "/api/users", map[string]string{"name": "bob"}, nil); status != http.StatusConflict {
+		t.Errorf("creating bob again returned %d, want %d", status, http.StatusConflict)
+	}
+
+	alice := api.as(apiUserHeader, "alice")
+	for _, test := range []struct {
+		client apiClient
+		want   int
+	}{
+		{api, http.StatusUnauthorized},
+		{alice, http.StatusOK},
+		{api.as(apiUserHeader, "nobody"), http.StatusUnauthorized},
+		// Users with a password need a session token.
+		{api.as(apiUserHeader, "bob"), http.StatusUnauthorized},
+		{api.as("Authorization", "Bearer not-a-token"), http.StatusUnauthorized},
+	} {
+		if status := test.client.do("GET", "/api/follows", nil, nil); status != test.want {
+			t.Errorf("GET /api/follows with %v returned %d, want %d", test.client.header, status, test.want)
+		}
+	}
+
+	if status := api.do("POST", "/api/sessions", map[string]string{"name": "bob", "password": "wrong"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("logging in with the wrong password returned %d", status)
+	}
+	var session struct{ Token string }
+	if status := api.do("POST", "/api/sessions", map[string]string{"name": "bob", "password": "correct horse"}, &session); status != http.StatusOK {
+		t.Fatalf("logging in returned %d", status)
+	}
+	bob := api.as("Authorization", "Bearer "+session.Token)
+
+	// The token wins over X-Gator-User, so bob can't list the users like
+	// alice, the admin, can.
+	bobAsAlice := bob
+	bobAsAlice.header = bob.header.Clone()
+	bobAsAlice.header.Set(apiUserHeader, "alice")
+	if status := bobAsAlice.do("GET", "/api/users", nil, nil); status != http.StatusForbidden {
+		t.Errorf("bob listed the users with %d", status)
+	}
+	var users []map[string]any
+	if status := alice.do("GET", "/api/users", nil, &users); status != http.StatusOK || len(users) != 2 {
+		t.Errorf("alice listed %d users with %d", len(users), status)
+	}
+	for _, user := range users {
+		if _, ok := user["password_hash"]; ok {
+			t.Error("the API returned a password hash")
+		}
+	}
+
+	if status := bob.do("DELETE", "/api/sessions", nil, nil); status != http.StatusNoContent {
+		t.Errorf("logging out returned %d", status)
+	}
+	if status := bob.do("GET", "/api/follows", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("a deleted session returned %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestAPIFeedsAndPosts(t *testing.T) {
+	s, server := newAPIServer(t)
+	now := time.Now()
+	items := []rssItem{}
+	for i := range 5 {
+		items = append(items, rssItem{fmt.Sprintf("Post %d", i), now.Add(-time.Duration(i) * time.Hour)})
+	}
+	feedURL := newFeedServer(t, items...).URL + "/feed.xml"
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "register", "bob")
+	alice := apiClient{t: t, url: server.URL}.as(apiUserHeader, "alice")
+	bob := alice.as(apiUserHeader, "bob")
+
+	var feed map[string]any
+	if status := alice.do("POST", "/api/feeds", map[string]string{"name": "Test", "url": feedURL}, &feed); status != http.StatusOK || feed["url"] != feedURL {
+		t.Fatalf("adding a feed returned %d: %v", status, feed)
+	}
+	if status := alice.do("POST", "/api/fetch", map[string]string{"url": feedURL}, &feed); status != http.StatusOK || feed["last_fetched_at"] == nil {
+		t.Fatalf("fetching the feed returned %d: %v", status, feed)
+	}
+
+	type post struct {
+		ID        string
+		Title     string
+		ReadAt    *string `json:"read_at"`
+		StarredAt *string `json:"starred_at"`
+	}
+	listPosts := func(client apiClient, query string) []string {
+		t.Helper()
+		var posts []post
+		if status := client.do("GET", "/api/posts"+query, nil, &posts); status != http.StatusOK {
+			t.Fatalf("GET /api/posts%s returned %d", query, status)
+		}
+		titles := []string{}
+		for _, p := range posts {
+			titles = append(titles, p.Title)
+		}
+		return titles
+	}
+
+	if got := listPosts(alice, "?limit=2&offset=1"); !slices.Equal(got, []string{"Post 1", "Post 2"}) {
+		t.Errorf("the second page is %q", got)
+	}
+	if got := listPosts(alice, "?q=post+4"); !slices.Equal(got, []string{"Post 4"}) {
+		t.Errorf("searching for post 4 found %q", got)
+	}
+	if got := listPosts(bob, ""); len(got) != 0 {
+		t.Errorf("bob sees posts of unfollowed feeds: %q", got)
+	}
+
+	var posts []post
+	alice.do("GET", "/api/posts?limit=1", nil, &posts)
+	first := "/api/posts/" + posts[0].ID
+	for _, path := range []string{first + "/read", first + "/star"} {
+		if status := alice.do("PUT", path, nil, nil); status != http.StatusNoContent {
+			t.Errorf("PUT %s returned %d", path, status)
+		}
+	}
+	var got post
+	if alice.do("GET", first, nil, &got); got.Title != "Post 0" {
+		t.Errorf("GET %s returned %v", first, got)
+	}
+	if got := listPosts(alice, "?unread=true"); len(got) != 4 || slices.Contains(got, "Post 0") {
+		t.Errorf("the unread posts are %q", got)
+	}
+	if got := listPosts(alice, "?starred=true"); !slices.Equal(got, []string{"Post 0"}) {
+		t.Errorf("the starred posts are %q", got)
+	}
+	if status := alice.do("DELETE", first+"/read", nil, nil); status != http.StatusNoContent {
+		t.Errorf("DELETE %s/read returned %d", first, status)
+	}
+	if got := listPosts(alice, "?unread=true"); len(got) != 5 {
+		t.Errorf("%d posts are unread, want 5", len(got))
+	}
+
+	// Another user's follows.
+	if status := bob.do("GET", first, nil, nil); status != http.StatusNotFound {
+		t.Errorf("bob got a post of an unfollowed feed with %d", status)
+	}
+	if status := bob.do("POST", "/api/follows", map[string]string{"url": feedURL, "folder": "news"}, nil); status != http.StatusOK {
+		t.Errorf("following the feed returned %d", status)
+	}
+	if got := listPosts(bob, "?folder=news&limit=100"); len(got) != 5 {
+		t.Errorf("bob's news folder has %d posts, want 5", len(got))
+	}
+	if status := bob.do("DELETE", "/api/follows?url="+feedURL, nil, nil); status != http.StatusNoContent {
+		t.Errorf("unfollowing the feed returned %d", status)
+	}
+	if got := listPosts(bob, ""); len(got) != 0 {
+		t.Errorf("bob still sees %d posts after unfollowing", len(got))
+	}
+
+	for _, path := range []string{"/api/posts?limit=0", "/api/posts?limit=101", "/api/posts?offset=-1", "/api/posts?unread=maybe", "/api/posts/not-an-id"} {
+		if status := alice.do("GET", path, nil, nil); status != http.StatusBadRequest {
+			t.Errorf("GET %s returned %d, want %d", path, status, http.StatusBadRequest)
+		}
+	}
+	if status := alice.do("POST", "/api/follows", map[string]string{"url": "https://example.com/none.xml"}, nil); status != http.StatusNotFound {
+		t.Errorf("following an unknown feed returned %d", status)
+	}
+}
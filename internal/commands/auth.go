@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+const sessionLifetime = 30 * 24 * time.Hour
+
+var errWrongPassword = errors.New("wrong user name or password")
+
+const newPasswordPrompt = "Password (leave empty for none): "
+
+// readPassword prompts for a password without echoing it. When stdin is
+// not a terminal the password is read from the first line of stdin, so
+// scripts can pipe it in.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("can not read password: %v", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", nil
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNewPassword asks for a password twice. An empty password means the
+// user has none.
+func readNewPassword(prompt string) (string, error) {
+	password, err := readPassword(prompt)
+	if err != nil || password == "" {
+		return "", err
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		confirm, err := readPassword("Confirm password: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm != password {
+			return "", fmt.Errorf("passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+func hashPassword(password string) (sql.NullString, error) {
+	if password == "" {
+		return sql.NullString{}, nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("can not hash password: %v", err)
+	}
+	return sqlString(string(hash)), nil
+}
+
+func checkPassword(user database.User, password string) error {
+	if !user.PasswordHash.Valid {
+		return nil
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(password)) != nil {
+		return errWrongPassword
+	}
+	return nil
+}
+
+// createSession returns a new session token for the user. Only a hash of
+// the token is stored in the database.
+func createSession(ctx context.Context, s *state, user database.User) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	err := s.db.CreateSession(ctx, database.CreateSessionParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: sqlCurrentTime(),
+		ExpiresAt: time.Now().UTC().Add(sessionLifetime),
+	})
+	if err != nil {
+		return "", fmt.Errorf("can not create session: %v", err)
+	}
+	return token, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionUser(ctx context.Context, s *state, token string) (database.User, error) {
+	return s.db.GetSessionUser(ctx, database.GetSessionUserParams{
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().UTC(),
+	})
+}
+
+// currentUser returns the user of the session stored in the config. Users
+// without a password can also be selected by name only.
+func currentUser(s *state) (database.User, error) {
+	if s.cfg.SessionToken != "" {
+		user, err := sessionUser(s.ctx, s, s.cfg.SessionToken)
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, fmt.Errorf("your session has expired, log in again with: gator login %s", s.cfg.CurrentUserName)
+		}
+		return user, err
+	}
+
+	user, err := s.db.GetUser(s.ctx, s.cfg.CurrentUserName)
+	if err != nil {
+		return database.User{}, err
+	}
+	if user.PasswordHash.Valid {
+		return database.User{}, fmt.Errorf("%s has a password, log in with: gator login %s", user.Name, user.Name)
+	}
+	return user, nil
+}
+
+func handlerLogout(s *state, cmd command) error {
+	if s.cfg.SessionToken != "" {
+		err := s.db.DeleteSession(s.ctx, hashToken(s.cfg.SessionToken))
+		if err != nil {
+			return fmt.Errorf("failed to end session: %v", err)
+		}
+	}
+
+	if err := s.cfg.SetSession("", ""); err != nil {
+		return err
+	}
+	fmt.Println("Logged out")
+	return nil
+}
+
+// handlerPasswd changes the password of the current user and ends all of
+// their other sessions.
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	password, err := readNewPassword(newPasswordPrompt)
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	ctx := s.ctx
+	err = s.db.SetUserPassword(ctx, database.SetUserPasswordParams{
+		PasswordHash: hash,
+		UpdatedAt:    sqlCurrentTime(),
+		ID:           user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+
+	if err := s.db.DeleteSessionsForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to end sessions: %v", err)
+	}
+	token, err := createSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	if err := s.cfg.SetSession(user.Name, token); err != nil {
+		return err
+	}
+
+	if password == "" {
+		fmt.Printf("Password removed for %s\n", user.Name)
+	} else {
+		fmt.Printf("Password changed for %s\n", user.Name)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+)
+
+func TestSessions(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "secret\n", "register", "alice")
+	alice, err := s.db.GetUser(s.ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.PasswordHash.String == "secret" {
+		t.Error("the password is stored in the clear")
+	}
+
+	// Only a hash of the token is stored, so the token itself is no use
+	// to someone who can read the database.
+	token := s.cfg.SessionToken
+	if _, err := s.db.GetSessionUser(s.ctx, database.GetSessionUserParams{TokenHash: token, ExpiresAt: time.Now().UTC()}); err == nil {
+		t.Error("the session token is stored in the clear")
+	}
+	if user, err := sessionUser(s.ctx, s, token); err != nil || user.ID != alice.ID {
+		t.Errorf("the session belongs to %v: %v", user.Name, err)
+	}
+
+	// A user with a password can't be picked by name only.
+	if err := s.cfg.SetSession("alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, s, "", "following"); err == nil || !strings.Contains(err.Error(), "has a password") {
+		t.Errorf("following without a session: %v", err)
+	}
+
+	expired := "expired-token"
+	err = s.db.CreateSession(s.ctx, database.CreateSessionParams{
+		TokenHash: hashToken(expired),
+		UserID:    alice.ID,
+		CreatedAt: sqlCurrentTime(),
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.cfg.SetSession("alice", expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, s, "", "following"); err == nil || !strings.Contains(err.Error(), "session has expired") {
+		t.Errorf("following with an expired session: %v", err)
+	}
+}
+
+func TestPasswd(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "secret\n", "register", "alice")
+	other, err := createSession(s.ctx, s, mustGetUser(t, s, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output := mustRun(t, s, "new secret\n", "passwd"); !strings.Contains(output, "Password changed for alice") {
+		t.Errorf("passwd printed %q", output)
+	}
+	if _, err := sessionUser(s.ctx, s, other); err == nil {
+		t.Error("passwd kept the other sessions")
+	}
+	mustRun(t, s, "", "following")
+
+	if _, err := runCommand(t, s, "secret\n", "login", "alice"); err != errWrongPassword {
+		t.Errorf("login with the old password: %v, want %v", err, errWrongPassword)
+	}
+	mustRun(t, s, "new secret\n", "login", "alice")
+
+	if output := mustRun(t, s, "\n", "passwd"); !strings.Contains(output, "Password removed for alice") {
+		t.Errorf("passwd printed %q", output)
+	}
+	if mustGetUser(t, s, "alice").PasswordHash.Valid {
+		t.Error("alice still has a password")
+	}
+	mustRun(t, s, "", "login", "alice")
+}
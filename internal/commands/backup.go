@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/babanini95/gatorcli/internal/backup"
+	"github.com/babanini95/gatorcli/internal/database"
+)
+
+// stdio is the file argument of backup and restore that stands for stdout
+// and stdin, e.g. "gator backup - | gator --profile prod restore -".
+const stdio = "-"
+
+func handlerBackup(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("backup command expected a file, or - for stdout")
+	}
+	file := cmd.arguments[0]
+
+	if file == stdio {
+		counts, err := backup.Write(s.ctx, s.db, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("backup failed: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Backed up %s\n", describeCounts(counts))
+		return nil
+	}
+
+	// The backup holds password hashes, so it is only readable by its
+	// owner, and it replaces the file only once it is complete.
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".gator-backup-*")
+	if err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	counts, err := backup.Write(s.ctx, s.db, tmp)
+	if err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	fmt.Printf("Backed up %s to %s\n", describeCounts(counts), file)
+	return nil
+}
+
+func handlerRestore(s *state, cmd command) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	merge := flags.Bool("merge", false, "add the backup to a database that already has data")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("restore: %v", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("restore command expected a file, or - for stdin")
+	}
+	file := flags.Arg(0)
+
+	restore := func(s *state, cmd command, user database.User) error {
+		return restoreBackup(s, file, *merge)
+	}
+	if *merge {
+		// Anyone may fill an empty database, but only admins may add
+		// users and feeds to one that is in use.
+		return middlewareAdmin(restore)(s, cmd)
+	}
+	return restore(s, cmd, database.User{})
+}
+
+func restoreBackup(s *state, file string, merge bool) error {
+	r := os.Stdin
+	if file != stdio {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("restore failed: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	counts, err := backup.Restore(s.ctx, s.db, r, merge)
+	if errors.Is(err, backup.ErrNotEmpty) {
+		return fmt.Errorf("the database is not empty, add the backup to it with: gator restore --merge %s", file)
+	}
+	if err != nil {
+		return fmt.Errorf("restore failed, nothing was restored: %v", err)
+	}
+
+	return s.render(counts, func() {
+		fmt.Printf("Restored %s\n", describeCounts(counts))
+		var skipped int64
+		for _, count := range counts {
+			skipped += count.Skipped
+		}
+		if skipped > 0 {
+			fmt.Printf("Skipped %d rows that were already in the database or refer to rows missing from the backup\n", skipped)
+		}
+	})
+}
+
+// describeCounts lists the rows per table, e.g. "2 users, 3 feeds".
+func describeCounts(counts []backup.Count) string {
+	parts := []string{}
+	for _, count := range counts {
+		parts = append(parts, fmt.Sprintf("%d %s", count.Rows, count.Table))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,17 +1,26 @@
 package commands
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/babanini95/gatorcli/internal/config"
 	"github.com/babanini95/gatorcli/internal/database"
 )
 
 type state struct {
-	cfg *config.Config
-	db  *database.Queries
+	cfg      *config.Config
+	db       database.Store
+	conn     *sql.DB
+	driver   string
+	cmds     *commands
+	template string
+	// ctx is cancelled by Ctrl-C or when the command times out.
+	ctx context.Context
 }
 
 type command struct {
@@ -21,26 +30,84 @@ type command struct {
 
 type commands struct {
 	cmds map[string]func(*state, command) error
+	subs map[string]map[string]func(*state, command) error
 }
 
 var commandMap = map[string]func(*state, command) error{
-	"reset":     handlerReset,
+	"reset":     middlewareAdmin(handlerReset),
 	"register":  handlerRegister,
 	"login":     handlerLogin,
-	"users":     handlerUsers,
+	"logout":    handlerLogout,
+	"passwd":    middlewareLoggedIn(handlerPasswd),
+	"users":     middlewareAdmin(handlerUsers),
 	"feeds":     handlerFeeds,
 	"addfeed":   middlewareLoggedIn(handlerAddFeed),
 	"follow":    middlewareLoggedIn(handlerFollow),
 	"following": middlewareLoggedIn(handlerFollowing),
 	"unfollow":  middlewareLoggedIn(handlerUnfollow),
-	"agg":       middlewareLoggedIn(handlerAgg),
+	"agg":       handlerAgg,
 	"browse":    middlewareLoggedIn(handlerBrowse),
+	"search":    middlewareLoggedIn(handlerSearch),
+	"folder":    middlewareLoggedIn(handlerFolder),
+	"tui":       middlewareLoggedIn(handlerTUI),
+	"watch":     middlewareLoggedIn(handlerWatch),
+	"serve":     handlerServe,
+	"fever":     middlewareLoggedIn(handlerFever),
+	"prune":     middlewareAdmin(handlerPrune),
+	"backup":    middlewareAdmin(handlerBackup),
+	"restore":   handlerRestore,
+
+	"__complete": handlerComplete,
+}
+
+// subcommandMap holds commands that take a subcommand as their first
+// argument, e.g. "gator completion bash".
+var subcommandMap = map[string]map[string]func(*state, command) error{
+	"completion": {
+		"bash": handlerCompletionBash,
+		"zsh":  handlerCompletionZsh,
+		"fish": handlerCompletionFish,
+	},
+	"feed": {
+		"rm":        middlewareLoggedIn(handlerFeedRm),
+		"rename":    middlewareLoggedIn(handlerFeedRename),
+		"set-url":   middlewareLoggedIn(handlerFeedSetURL),
+		"show":      handlerFeedShow,
+		"retention": middlewareLoggedIn(handlerFeedRetention),
+	},
+	"config": {
+		"get":  handlerConfigGet,
+		"set":  handlerConfigSet,
+		"path": handlerConfigPath,
+	},
+	"profile": {
+		"list": handlerProfileList,
+		"use":  handlerProfileUse,
+		"add":  handlerProfileAdd,
+	},
+	"user": {
+		"delete":  middlewareLoggedIn(handlerUserDelete),
+		"rename":  middlewareLoggedIn(handlerUserRename),
+		"promote": middlewareAdmin(handlerUserPromote),
+		"demote":  middlewareAdmin(handlerUserDemote),
+	},
+	"export": {
+		"feed": middlewareLoggedIn(handlerExportFeed),
+	},
+	"migrate": {
+		"up":     handlerMigrateUp,
+		"down":   handlerMigrateDown,
+		"status": handlerMigrateStatus,
+	},
 }
 
 func (c *commands) generateCommands() {
 	for name, fn := range commandMap {
 		c.register(name, fn)
 	}
+	for name, subs := range subcommandMap {
+		c.registerGroup(name, subs)
+	}
 }
 
 func (c *commands) run(s *state, cmd command) error {
@@ -48,6 +115,11 @@ func (c *commands) run(s *state, cmd command) error {
 	if !ok {
 		return fmt.Errorf("command unavailable")
 	}
+	if !noSchemaCommands[cmd.name] {
+		if err := checkSchema(s); err != nil {
+			return err
+		}
+	}
 
 	err := f(s, cmd)
 	if err != nil {
@@ -61,7 +133,48 @@ func (c *commands) register(name string, f func(*state, command) error) {
 	c.cmds[name] = f
 }
 
-func (c *commands) Run(s *state, args []string) {
+func (c *commands) registerGroup(name string, subs map[string]func(*state, command) error) {
+	c.subs[name] = subs
+	c.register(name, func(s *state, cmd command) error {
+		if len(cmd.arguments) == 0 {
+			return fmt.Errorf("%s command expected a subcommand: %s", name, strings.Join(sortedKeys(subs), ", "))
+		}
+
+		f, ok := subs[cmd.arguments[0]]
+		if !ok {
+			return fmt.Errorf("unknown %s subcommand: %s", name, cmd.arguments[0])
+		}
+
+		return f(s, command{
+			name:      name + " " + cmd.arguments[0],
+			arguments: cmd.arguments[1:],
+		})
+	})
+}
+
+// names returns the sorted names of all visible commands.
+func (c *commands) names() []string {
+	names := []string{}
+	for _, name := range sortedKeys(c.cmds) {
+		if !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Run runs the command in args. Cancelling ctx, e.g. on Ctrl-C, cancels
+// its queries and fetches.
+func (c *commands) Run(ctx context.Context, s *state, args []string) {
+	if len(args) >= 2 && args[1] != "__complete" {
+		rest, err := parseGlobalFlags(s, args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		args = append(args[:1], rest...)
+	}
+
 	if len(args) < 2 {
 		fmt.Fprintln(os.Stderr, "not enough arguments")
 		os.Exit(1)
@@ -74,8 +187,25 @@ func (c *commands) Run(s *state, args []string) {
 		cmd.arguments = args[2:]
 	}
 
+	timeout := commandTimeout(s, cmd.name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	s.cmds = c
+	s.ctx = ctx
 	err := c.run(s, cmd)
-	if err != nil {
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		fmt.Fprintf(os.Stderr, "%s timed out after %s: %v\n", cmd.name, timeout, err)
+		os.Exit(1)
+	case errors.Is(ctx.Err(), context.Canceled):
+		fmt.Fprintln(os.Stderr, "interrupted")
+		os.Exit(130)
+	default:
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
@@ -86,25 +216,62 @@ func (s *state) SaveConfig(c *config.Config) {
 }
 
 func (s *state) CreateQueries() error {
-	db, err := sql.Open("postgres", s.cfg.DbURL)
+	driver, dsn, err := database.Driver(s.cfg.DbURL)
+	if err != nil {
+		return fmt.Errorf("queries can not be created: %v", err)
+	}
+	if driver == "memory" {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		s.db = database.NewMemoryStore()
+		s.conn = nil
+		s.driver = driver
+		return nil
+	}
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return fmt.Errorf("queries can not be created: %v", err)
 	}
+	setPool(db, s.cfg.Pool)
+	// --profile and --db-url replace the connection opened for the config.
+	if s.conn != nil {
+		s.conn.Close()
+	}
 
 	s.db = database.New(db)
+	s.conn = db
+	s.driver = driver
 	return nil
 }
 
+// setPool applies the pool settings of the config to db.
+func setPool(db *sql.DB, pool config.Pool) {
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if lifetime := pool.ConnMaxLifetimeDuration(); lifetime > 0 {
+		db.SetConnMaxLifetime(lifetime)
+	}
+	if idleTime := pool.ConnMaxIdleTimeDuration(); idleTime > 0 {
+		db.SetConnMaxIdleTime(idleTime)
+	}
+}
+
 func CreateNewState(c *config.Config) (*state, error) {
 	if c == nil {
 		return &state{}, fmt.Errorf("config is empty")
 	}
-	return &state{cfg: c}, nil
+	return &state{cfg: c, ctx: context.Background()}, nil
 }
 
 func InitCommands() *commands {
 	cmds := &commands{
 		cmds: make(map[string]func(*state, command) error),
+		subs: make(map[string]map[string]func(*state, command) error),
 	}
 
 	cmds.generateCommands()
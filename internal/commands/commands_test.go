@@ -0,0 +1,390 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/config"
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// newTestState returns a state on an empty in-memory store. The config,
+// cache and agg lock live in a temporary directory.
+func newTestState(t *testing.T) *state {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	for _, name := range []string{"GATOR_USER", "GATOR_SESSION_TOKEN", "GATOR_PROFILE", "GATOR_OUTPUT", "GATOR_TIMEOUT"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GATOR_DB_URL", "memory://")
+
+	cfg, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := CreateNewState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateQueries(); err != nil {
+		t.Fatal(err)
+	}
+	s.cmds = InitCommands()
+	return s
+}
+
+// runCommand runs a command with input on stdin and returns what it
+// printed to stdout. Prompts on stderr are discarded.
+func runCommand(t *testing.T, s *state, input string, name string, args ...string) (string, error) {
+	t.Helper()
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	if _, err := stdin.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	return captureOutput(t, func() error {
+		return s.cmds.run(s, command{name: name, arguments: args})
+	})
+}
+
+// captureOutput runs fn and returns what it printed to stdout. Prompts on
+// stderr are discarded.
+func captureOutput(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, stderr
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+
+	output := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		output <- string(b)
+	}()
+
+	err = fn()
+	w.Close()
+	return <-output, err
+}
+
+// mustRun is runCommand for commands that have to succeed.
+func mustRun(t *testing.T, s *state, input string, name string, args ...string) string {
+	t.Helper()
+	output, err := runCommand(t, s, input, name, args...)
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, output)
+	}
+	return output
+}
+
+// mustGetUser returns the user, who has to exist.
+func mustGetUser(t *testing.T, s *state, name string) database.User {
+	t.Helper()
+	user, err := s.db.GetUser(s.ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// rssItem is an item served by newFeedServer.
+type rssItem struct {
+	title     string
+	published time.Time
+}
+
+// newFeedServer serves items as an RSS feed at /feed.xml. Any other path
+// fails.
+func newFeedServer(t *testing.T, items ...rssItem) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>`)
+		for _, item := range items {
+			fmt.Fprintf(w, "<item><title>%s</title><link>https://example.com/%s</link><description>About %s</description><pubDate>%s</pubDate></item>",
+				item.title, strings.ReplaceAll(item.title, " ", "-"), item.title, item.published.Format(time.RFC1123Z))
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestState(t)
+
+	output := mustRun(t, s, "secret\n", "register", "alice")
+	if !strings.Contains(output, "User alice has been created") {
+		t.Errorf("register printed %q", output)
+	}
+	if s.cfg.CurrentUserName != "alice" || s.cfg.SessionToken == "" {
+		t.Errorf("after register the config has user %q and token %q", s.cfg.CurrentUserName, s.cfg.SessionToken)
+	}
+	alice, err := s.db.GetUser(s.ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Role != roleAdmin {
+		t.Errorf("the first user is %s, want %s", alice.Role, roleAdmin)
+	}
+
+	mustRun(t, s, "", "register", "bob")
+	bob, err := s.db.GetUser(s.ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Role != roleMember || bob.PasswordHash.Valid {
+		t.Errorf("bob has role %s and password %v, want %s without password", bob.Role, bob.PasswordHash.Valid, roleMember)
+	}
+	if s.cfg.CurrentUserName != "bob" {
+		t.Errorf("after register the current user is %q, want bob", s.cfg.CurrentUserName)
+	}
+
+	if _, err := runCommand(t, s, "wrong\n", "login", "alice"); err != errWrongPassword {
+		t.Errorf("login with the wrong password: %v, want %v", err, errWrongPassword)
+	}
+	if s.cfg.CurrentUserName != "bob" {
+		t.Errorf("a failed login changed the current user to %q", s.cfg.CurrentUserName)
+	}
+
+	bobToken := s.cfg.SessionToken
+	mustRun(t, s, "secret\n", "login", "alice")
+	if s.cfg.CurrentUserName != "alice" {
+		t.Errorf("after login the current user is %q, want alice", s.cfg.CurrentUserName)
+	}
+	if _, err := sessionUser(s.ctx, s, bobToken); err == nil {
+		t.Error("the session of the previous user is still valid after login")
+	}
+
+	// The session is written to the config file.
+	cfg, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CurrentUserName != "alice" || cfg.SessionToken != s.cfg.SessionToken {
+		t.Errorf("the config file has user %q and token %q", cfg.CurrentUserName, cfg.SessionToken)
+	}
+
+	mustRun(t, s, "", "logout")
+	if s.cfg.SessionToken != "" {
+		t.Error("logout kept the session token")
+	}
+	if _, err := runCommand(t, s, "", "following"); err == nil {
+		t.Error("following succeeded after logout")
+	}
+}
+
+func TestAddFeedAndFollow(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", "https://example.com/feed.xml")
+
+	if output := mustRun(t, s, "", "following"); output != "Blog\n" {
+		t.Errorf("alice follows %q, want the feed alice added", output)
+	}
+
+	mustRun(t, s, "", "register", "bob")
+	if output := mustRun(t, s, "", "following"); output != "" {
+		t.Errorf("bob follows %q before following anything", output)
+	}
+	output := mustRun(t, s, "", "follow", "https://example.com/feed.xml", "Reading")
+	if !strings.Contains(output, "Feed name: Blog") || !strings.Contains(output, "User: bob") {
+		t.Errorf("follow printed %q", output)
+	}
+
+	bob, err := s.db.GetUser(s.ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	follows, err := s.db.GetFeedFollowsForUser(s.ctx, uuid.NullUUID{UUID: bob.ID, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 1 || follows[0].FeedName.String != "Blog" || follows[0].Folder.String != "Reading" {
+		t.Errorf("bob follows %+v, want Blog in Reading", follows)
+	}
+
+	mustRun(t, s, "", "unfollow", "https://example.com/feed.xml")
+	if output := mustRun(t, s, "", "following"); output != "" {
+		t.Errorf("bob follows %q after unfollowing", output)
+	}
+
+	output = mustRun(t, s, "", "feeds")
+	if !strings.Contains(output, "Blog") || !strings.Contains(output, "alice") {
+		t.Errorf("feeds printed %q", output)
+	}
+}
+
+func TestAggAndBrowse(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	server := newFeedServer(t,
+		rssItem{"First post", now.Add(-3 * time.Hour)},
+		rssItem{"Second post", now.Add(-2 * time.Hour)},
+		rssItem{"Third post", now.Add(-time.Hour)},
+	)
+
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+	mustRun(t, s, "", "addfeed", "Broken", server.URL+"/missing.xml")
+
+	output := mustRun(t, s, "", "agg", "--once")
+	if !strings.Contains(output, "Fetched Test: 3 new posts, 0 already saved, 0 failed") {
+		t.Errorf("agg printed %q", output)
+	}
+	// The broken feed doesn't keep agg from fetching the other one.
+	if !strings.Contains(output, "Fetched 1 of 2 feeds") {
+		t.Errorf("agg printed %q, want 1 of 2 feeds fetched", output)
+	}
+
+	output = mustRun(t, s, "", "agg", "--once")
+	if !strings.Contains(output, "Fetched Test: 0 new posts, 3 already saved, 0 failed") {
+		t.Errorf("second agg printed %q", output)
+	}
+
+	feed, err := s.db.GetFeedByUrl(s.ctx, sqlString(server.URL+"/missing.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !feed.LastFetchedAt.Valid {
+		t.Error("the broken feed is not marked fetched")
+	}
+
+	output = mustRun(t, s, "", "browse", "10")
+	first, second, third := strings.Index(output, "First post"), strings.Index(output, "Second post"), strings.Index(output, "Third post")
+	if first < 0 || second < 0 || third < 0 {
+		t.Fatalf("browse printed %q, want all three posts", output)
+	}
+	if !(third < second && second < first) {
+		t.Errorf("browse printed %q, want the newest post first", output)
+	}
+
+	output = mustRun(t, s, "", "browse")
+	if strings.Count(output, "Title:") != 2 {
+		t.Errorf("browse printed %q, want the 2 newest posts", output)
+	}
+
+	output = mustRun(t, s, "", "search", "second")
+	if strings.Count(output, "Title:") != 1 || !strings.Contains(output, "Second post") {
+		t.Errorf("search printed %q", output)
+	}
+}
+
+func TestAggNeedsLogin(t *testing.T) {
+	s := newTestState(t)
+	if _, err := runCommand(t, s, "", "agg", "--once"); err == nil {
+		t.Error("agg succeeded without a user")
+	}
+	// --all runs without one.
+	if output := mustRun(t, s, "", "agg", "--once", "--all"); !strings.Contains(output, "Fetched 0 of 0 feeds") {
+		t.Errorf("agg --all printed %q", output)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	server := newFeedServer(t,
+		rssItem{"Old post", now.Add(-60 * 24 * time.Hour)},
+		rssItem{"Older post", now.Add(-90 * 24 * time.Hour)},
+		rssItem{"New post", now.Add(-time.Hour)},
+	)
+
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+	mustRun(t, s, "", "agg", "--once")
+
+	mustRun(t, s, "", "config", "set", "retention.max_age", "30d")
+	output := mustRun(t, s, "", "prune", "--dry-run")
+	if !strings.Contains(output, "2 posts would be removed") {
+		t.Errorf("prune --dry-run printed %q", output)
+	}
+	if output := mustRun(t, s, "", "browse", "10"); strings.Count(output, "Title:") != 3 {
+		t.Errorf("prune --dry-run removed posts, browse printed %q", output)
+	}
+
+	mustRun(t, s, "", "register", "bob")
+	if _, err := runCommand(t, s, "", "prune"); err == nil {
+		t.Error("a member could prune")
+	}
+	mustRun(t, s, "", "login", "alice")
+
+	output = mustRun(t, s, "", "prune")
+	if !strings.Contains(output, "2 posts removed") {
+		t.Errorf("prune printed %q", output)
+	}
+	output = mustRun(t, s, "", "browse", "10")
+	if strings.Count(output, "Title:") != 1 || !strings.Contains(output, "New post") {
+		t.Errorf("after prune browse printed %q, want only the new post", output)
+	}
+}
+
+func TestAggAllPrunes(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	server := newFeedServer(t,
+		rssItem{"Old post", now.Add(-60 * 24 * time.Hour)},
+		rssItem{"New post", now.Add(-time.Hour)},
+	)
+
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+
+	mustRun(t, s, "", "config", "set", "retention.max_age", "30d")
+	output := mustRun(t, s, "", "agg", "--once", "--all")
+	if !strings.Contains(output, "Pruned 1 posts from Test") {
+		t.Errorf("agg --all printed %q, want the old post pruned", output)
+	}
+
+	bob, err := s.db.GetUser(s.ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := s.db.GetPostsForUser(s.ctx, database.GetPostsForUserParams{
+		UserID: uuid.NullUUID{UUID: bob.ID, Valid: true},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].Title.String != "New post" {
+		t.Errorf("bob has %d posts after agg --all, want only the new post", len(posts))
+	}
+}
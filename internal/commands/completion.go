@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// argCompleters returns the candidates for each argument of a command, by
+// position. Subcommands are keyed as "<command> <subcommand>".
+var argCompleters = map[string][]func(*state) ([]string, error){
+	"login":          {completeUserNames},
+	"follow":         {completeFeedURLs, completeFolders},
+	"unfollow":       {completeFollowedFeedURLs},
+	"folder":         {completeFollowedFeedURLs, completeFolders},
+	"feed rm":        {completeFeedURLs},
+	"feed rename":    {completeFeedURLs},
+	"feed set-url":   {completeFeedURLs},
+	"feed show":      {completeFeedURLs},
+	"feed retention": {completeFeedURLs},
+	"config get":     {completeConfigKeys},
+	"config set":     {completeConfigKeys},
+	"profile use":    {completeProfiles},
+	"user delete":    {completeUserNames},
+	"user rename":    {completeUserNames},
+	"user promote":   {completeUserNames},
+	"user demote":    {completeUserNames},
+}
+
+const bashCompletionTemplate = `# bash completion for gator
+# Load it with: source <(gator completion bash)
+
+_gator() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null 2>&1; then
+        _get_comp_words_by_ref -n : cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+
+    local IFS=$'\n'
+    if [[ $cword -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+    else
+        COMPREPLY=($(gator __complete "${words[@]:1:cword}" 2>/dev/null))
+    fi
+
+    if declare -F __ltrim_colon_completions >/dev/null 2>&1; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+
+complete -o default -F _gator gator
+`
+
+const zshCompletionTemplate = `#compdef gator
+# Load it with: source <(gator completion zsh)
+
+_gator() {
+    local -a candidates
+    if (( CURRENT == 2 )); then
+        candidates=(%s)
+    else
+        candidates=("${(@f)$(gator __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    fi
+    compadd -a candidates
+}
+
+if [ "$funcstack[1]" = "_gator" ]; then
+    _gator "$@"
+else
+    compdef _gator gator
+fi
+`
+
+const fishCompletionTemplate = `# fish completion for gator
+# Load it with: gator completion fish | source
+
+complete -c gator -f
+complete -c gator -n __fish_use_subcommand -a "%s"
+complete -c gator -n "not __fish_use_subcommand" -a "(gator __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)"
+`
+
+func handlerCompletionBash(s *state, cmd command) error {
+	fmt.Printf(bashCompletionTemplate, strings.Join(s.cmds.names(), "\n"))
+	return nil
+}
+
+func handlerCompletionZsh(s *state, cmd command) error {
+	fmt.Printf(zshCompletionTemplate, strings.Join(s.cmds.names(), " "))
+	return nil
+}
+
+func handlerCompletionFish(s *state, cmd command) error {
+	fmt.Printf(fishCompletionTemplate, strings.Join(s.cmds.names(), " "))
+	return nil
+}
+
+// handlerComplete is called by the completion scripts with the words typed
+// after "gator". The last word is the one being completed and may be empty.
+func handlerComplete(s *state, cmd command) error {
+	words := cmd.arguments
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// Skip the global flags before the command name.
+	for len(words) > 0 {
+		name, _, hasValue := strings.Cut(words[0], "=")
+		if _, ok := globalFlags[name]; !ok {
+			break
+		}
+		if !hasValue && len(words) == 1 {
+			// current is the value of the flag.
+			return nil
+		}
+		if hasValue {
+			words = words[1:]
+		} else {
+			words = words[2:]
+		}
+	}
+
+	var candidates []string
+	switch {
+	case len(words) == 0:
+		candidates = s.cmds.names()
+	case len(words) == 1 && s.cmds.subs[words[0]] != nil:
+		candidates = sortedKeys(s.cmds.subs[words[0]])
+	default:
+		key := words[0]
+		args := words[1:]
+		if s.cmds.subs[key] != nil {
+			key += " " + words[1]
+			args = words[2:]
+		}
+
+		completers := argCompleters[key]
+		if len(args) >= len(completers) {
+			return nil
+		}
+
+		var err error
+		candidates, err = completers[len(args)](s)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+	return nil
+}
+
+func completeUserNames(s *state) ([]string, error) {
+	users, err := s.db.GetUsers(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func completeFeedURLs(s *state) ([]string, error) {
+	feeds, err := s.db.ListFeeds(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		urls = append(urls, feed.Url.String)
+	}
+	return urls, nil
+}
+
+func completeFollowedFeedURLs(s *state) ([]string, error) {
+	user, err := s.db.GetUser(s.ctx, s.cfg.CurrentUserName)
+	if err != nil {
+		return nil, err
+	}
+
+	feedsFollow, err := s.db.GetFeedFollowsForUser(
+		s.ctx,
+		uuid.NullUUID{
+			UUID:  user.ID,
+			Valid: true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(feedsFollow))
+	for _, feed := range feedsFollow {
+		urls = append(urls, feed.FeedUrl.String)
+	}
+	return urls, nil
+}
+
+func completeFolders(s *state) ([]string, error) {
+	user, err := s.db.GetUser(s.ctx, s.cfg.CurrentUserName)
+	if err != nil {
+		return nil, err
+	}
+
+	folders, err := s.db.GetFoldersForUser(
+		s.ctx,
+		uuid.NullUUID{
+			UUID:  user.ID,
+			Valid: true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		names = append(names, folder.String)
+	}
+	return names, nil
+}
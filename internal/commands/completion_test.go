@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	s := newTestState(t)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script := mustRun(t, s, "", "completion", shell)
+		for _, name := range s.cmds.names() {
+			if !strings.Contains(script, name) {
+				t.Errorf("the %s script doesn't complete %s", shell, name)
+			}
+		}
+		// Hidden commands are only called, never offered.
+		if n := strings.Count(script, "__complete"); n != 1 {
+			t.Errorf("the %s script mentions __complete %d times, want once", shell, n)
+		}
+
+		if _, err := exec.LookPath(shell); err != nil {
+			continue
+		}
+		check := exec.Command(shell, "-n")
+		if shell == "fish" {
+			check = exec.Command(shell, "--no-execute")
+		}
+		check.Stdin = strings.NewReader(script)
+		if output, err := check.CombinedOutput(); err != nil {
+			t.Errorf("the %s script doesn't parse: %v\n%s", shell, err, output)
+		}
+	}
+
+	if _, err := runCommand(t, s, "", "completion", "powershell"); err == nil {
+		t.Error("completion for an unknown shell succeeded")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := newTestState(t)
+	server := newFeedServer(t)
+	blog := server.URL + "/feed.xml"
+	news := server.URL + "/news.xml"
+
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "addfeed", "News", news)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", blog)
+	mustRun(t, s, "", "folder", blog, "tech")
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"logi"}, []string{"login"}},
+		{[]string{"login", ""}, []string{"alice", "bob"}},
+		{[]string{"login", "b"}, []string{"bob"}},
+		{[]string{"login", "alice", ""}, nil},
+		{[]string{"unfollow", ""}, []string{blog}},
+		{[]string{"follow", ""}, []string{blog, news}},
+		{[]string{"follow", news, ""}, []string{"tech"}},
+		{[]string{"feed", "re"}, []string{"rename", "retention"}},
+		{[]string{"feed", "rm", ""}, []string{blog, news}},
+		{[]string{"user", "promote", "a"}, []string{"alice"}},
+		{[]string{"config", "get", "retention.max_"}, []string{"retention.max_age", "retention.max_posts"}},
+		{[]string{"profile", "use", ""}, []string{"default"}},
+		// Global flags before the command are skipped, their values are
+		// not completed.
+		{[]string{"--output", "json", "login", "a"}, []string{"alice"}},
+		{[]string{"--output=json", "logi"}, []string{"login"}},
+		{[]string{"--output", ""}, nil},
+	}
+	for _, test := range tests {
+		output := mustRun(t, s, "", "__complete", test.words...)
+		got := strings.Fields(output)
+		slices.Sort(got)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("__complete %q printed %q, want %q", test.words, got, test.want)
+		}
+	}
+
+	output := mustRun(t, s, "", "__complete", "")
+	if got := strings.Fields(output); !slices.Equal(got, s.cmds.names()) {
+		t.Errorf("__complete printed %q, want the commands %q", got, s.cmds.names())
+	}
+}
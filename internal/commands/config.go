@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/babanini95/gatorcli/internal/config"
+)
+
+func handlerConfigGet(s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("config get command expected a key")
+	}
+
+	value, err := s.cfg.Get(cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func handlerConfigSet(s *state, cmd command) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("config set command expected a key and a value")
+	}
+
+	if err := s.cfg.Set(cmd.arguments[0], cmd.arguments[1]); err != nil {
+		return err
+	}
+	fmt.Printf("%s has been set\n", cmd.arguments[0])
+	return nil
+}
+
+func handlerConfigPath(s *state, cmd command) error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+func completeConfigKeys(s *state) ([]string, error) {
+	keys := append([]string{}, config.Keys...)
+	for _, name := range sortedKeys(s.cfg.Templates) {
+		keys = append(keys, "templates."+name)
+	}
+	return keys, nil
+}
@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	exportRSS          = "rss"
+	exportAtom         = "atom"
+	exportDefaultLimit = 50
+)
+
+type exportRSSFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string          `xml:"title"`
+		Link          string          `xml:"link"`
+		Description   string          `xml:"description"`
+		LastBuildDate string          `xml:"lastBuildDate"`
+		Generator     string          `xml:"generator"`
+		Items         []exportRSSItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type exportRSSItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link,omitempty"`
+	Description string `xml:"description"`
+	Guid        struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	} `xml:"guid"`
+	PubDate  string `xml:"pubDate,omitempty"`
+	Category string `xml:"category,omitempty"`
+}
+
+type exportAtomFeed struct {
+	XMLName   xml.Name          `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string            `xml:"title"`
+	ID        string            `xml:"id"`
+	Updated   string            `xml:"updated"`
+	Link      exportAtomLink    `xml:"link"`
+	Author    exportAtomAuthor  `xml:"author"`
+	Generator string            `xml:"generator"`
+	Entries   []exportAtomEntry `xml:"entry"`
+}
+
+type exportAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type exportAtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type exportAtomEntry struct {
+	Title     string          `xml:"title"`
+	ID        string          `xml:"id"`
+	Link      *exportAtomLink `xml:"link,omitempty"`
+	Updated   string          `xml:"updated"`
+	Published string          `xml:"published,omitempty"`
+	Summary   struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"summary"`
+	Category *struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category,omitempty"`
+}
+
+func handlerExportFeed(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("export feed", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", exportRSS, "rss or atom")
+	folder := flags.String("folder", "", "only export posts from feeds in this folder")
+	search := flags.String("search", "", "only export posts containing this text")
+	limit := flags.Int("limit", exportDefaultLimit, "number of posts to export")
+	link := flags.String("link", "", "link to the exported feed")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("export feed: %v", err)
+	}
+
+	if *link == "" {
+		*link = fmt.Sprintf("http://%s/export/%s/%s", defaultServeAddr, user.Name, *format)
+	}
+	params := exportParams(user, *folder, *search, *limit)
+	posts, err := s.db.ListPostsForUser(s.ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %v", err)
+	}
+
+	out := os.Stdout
+	if flags.NArg() > 0 {
+		out, err = os.Create(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	return writeExportFeed(out, *format, user, *link, posts)
+}
+
+// exportFeed serves the user's timeline at /export/{user}/{format}, with the
+// same folder, q and limit filters as the command. Users with a password
+// need a session token.
+func (api *apiServer) exportFeed(w http.ResponseWriter, r *http.Request) {
+	user, err := api.s.db.GetUser(r.Context(), r.PathValue("user"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if user.PasswordHash.Valid {
+		// Feed readers can't always set headers, so the session token
+		// may also be passed in the query.
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		owner, err := sessionUser(r.Context(), api.s, token)
+		if err != nil || owner.ID != user.ID {
+			http.Error(w, "a session token is required", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	format := r.PathValue("format")
+	if format != exportRSS && format != exportAtom {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"), exportDefaultLimit)
+	if err != nil || limit < 1 || limit > apiMaxPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", apiMaxPageSize), http.StatusBadRequest)
+		return
+	}
+
+	params := exportParams(user, query.Get("folder"), query.Get("q"), int(limit))
+	posts, err := api.s.db.ListPostsForUser(r.Context(), params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	link := "http://" + r.Host + r.URL.RequestURI()
+	if format == exportAtom {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	}
+	writeExportFeed(w, format, user, link, posts)
+}
+
+func exportParams(user database.User, folder, search string, limit int) database.ListPostsForUserParams {
+	params := database.ListPostsForUserParams{
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		Folder:    sqlString(folder),
+		PageLimit: int32(limit),
+	}
+	if search != "" {
+		params.Pattern = sqlString(containsPattern(search))
+	}
+	return params
+}
+
+func writeExportFeed(w io.Writer, format string, user database.User, link string, posts []database.ListPostsForUserRow) error {
+	var doc any
+	switch format {
+	case exportRSS:
+		doc = buildRSS(user, link, posts)
+	case exportAtom:
+		doc = buildAtom(user, link, posts)
+	default:
+		return fmt.Errorf("unknown feed format %q, expected rss or atom", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("can not encode feed: %v", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func buildRSS(user database.User, link string, posts []database.ListPostsForUserRow) exportRSSFeed {
+	feed := exportRSSFeed{Version: "2.0"}
+	feed.Channel.Title = fmt.Sprintf("%s's gator timeline", user.Name)
+	feed.Channel.Link = link
+	feed.Channel.Description = fmt.Sprintf("Posts from the feeds %s follows on gator", user.Name)
+	feed.Channel.LastBuildDate = time.Now().Format(time.RFC1123Z)
+	feed.Channel.Generator = "gator"
+
+	for _, post := range posts {
+		item := exportRSSItem{
+			Title:       post.Title.String,
+			Link:        post.Url.String,
+			Description: post.Description.String,
+			Category:    post.FeedName.String,
+		}
+		item.Guid.IsPermaLink = post.Url.Valid
+		item.Guid.Value = post.Url.String
+		if !post.Url.Valid {
+			item.Guid.Value = "urn:uuid:" + post.ID.String()
+		}
+		if post.PublishedAt.Valid {
+			item.PubDate = post.PublishedAt.Time.Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+func buildAtom(user database.User, link string, posts []database.ListPostsForUserRow) exportAtomFeed {
+	feed := exportAtomFeed{
+		Title:     fmt.Sprintf("%s's gator timeline", user.Name),
+		ID:        "urn:uuid:" + user.ID.String(),
+		Updated:   time.Now().Format(time.RFC3339),
+		Link:      exportAtomLink{Href: link, Rel: "self"},
+		Author:    exportAtomAuthor{Name: user.Name},
+		Generator: "gator",
+	}
+
+	for _, post := range posts {
+		updated := post.PublishedAt.Time
+		if !post.PublishedAt.Valid {
+			updated = post.CreatedAt.Time
+		}
+
+		entry := exportAtomEntry{
+			Title:   post.Title.String,
+			ID:      "urn:uuid:" + post.ID.String(),
+			Updated: updated.Format(time.RFC3339),
+		}
+		if post.PublishedAt.Valid {
+			entry.Published = post.PublishedAt.Time.Format(time.RFC3339)
+		}
+		if post.Url.Valid {
+			entry.Link = &exportAtomLink{Href: post.Url.String}
+		}
+		entry.Summary.Type = "html"
+		entry.Summary.Value = post.Description.String
+		if post.FeedName.Valid {
+			entry.Category = &struct {
+				Term string `xml:"term,attr"`
+			}{Term: post.FeedName.String}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rssTitles(t *testing.T, data string) []string {
+	t.Helper()
+	var feed exportRSSFeed
+	if err := xml.Unmarshal([]byte(data), &feed); err != nil {
+		t.Fatalf("the RSS feed doesn't parse: %v\n%s", err, data)
+	}
+	titles := []string{}
+	for _, item := range feed.Channel.Items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func TestExportFeed(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	blog := newFeedServer(t,
+		rssItem{"Blog post", now.Add(-time.Hour)},
+		rssItem{"Go release", now.Add(-3 * time.Hour)},
+	)
+	news := newFeedServer(t, rssItem{"News post", now.Add(-2 * time.Hour)})
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", blog.URL+"/feed.xml")
+	mustRun(t, s, "", "addfeed", "News", news.URL+"/feed.xml")
+	mustRun(t, s, "", "folder", news.URL+"/feed.xml", "news")
+	mustRun(t, s, "", "agg", "--once")
+
+	output := mustRun(t, s, "", "export", "feed")
+	if !strings.HasPrefix(output, xml.Header) {
+		t.Errorf("the feed doesn't start with an XML header: %q", output)
+	}
+	var rss exportRSSFeed
+	if err := xml.Unmarshal([]byte(output), &rss); err != nil {
+		t.Fatal(err)
+	}
+	if rss.Version != "2.0" || rss.Channel.Title != "alice's gator timeline" || !strings.HasSuffix(rss.Channel.Link, "/export/alice/rss") {
+		t.Errorf("the RSS channel is %+v", rss.Channel)
+	}
+	if len(rss.Channel.Items) != 3 {
+		t.Fatalf("the RSS feed has %d items, want 3", len(rss.Channel.Items))
+	}
+	item := rss.Channel.Items[0]
+	if item.Title != "Blog post" || item.Link != "https://example.com/Blog-post" || item.Category != "Blog" ||
+		!item.Guid.IsPermaLink || item.Guid.Value != item.Link {
+		t.Errorf("the first RSS item is %+v", item)
+	}
+	if _, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil {
+		t.Errorf("pubDate: %v", err)
+	}
+
+	if got := rssTitles(t, mustRun(t, s, "", "export", "feed", "--folder", "news")); !slices.Equal(got, []string{"News post"}) {
+		t.Errorf("--folder news exported %q", got)
+	}
+	if got := rssTitles(t, mustRun(t, s, "", "export", "feed", "--search", "go")); !slices.Equal(got, []string{"Go release"}) {
+		t.Errorf("--search go exported %q", got)
+	}
+	if got := rssTitles(t, mustRun(t, s, "", "export", "feed", "--limit", "2")); !slices.Equal(got, []string{"Blog post", "News post"}) {
+		t.Errorf("--limit 2 exported %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "timeline.xml")
+	mustRun(t, s, "", "export", "feed", "--format", "atom", "--link", "https://gator.example.com/alice.xml", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var atom exportAtomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		t.Fatal(err)
+	}
+	if atom.Link.Href != "https://gator.example.com/alice.xml" || atom.Link.Rel != "self" || atom.Author.Name != "alice" {
+		t.Errorf("the Atom feed is %+v", atom)
+	}
+	if len(atom.Entries) != 3 {
+		t.Fatalf("the Atom feed has %d entries, want 3", len(atom.Entries))
+	}
+	entry := atom.Entries[0]
+	if entry.Title != "Blog post" || entry.Link == nil || entry.Link.Href != "https://example.com/Blog-post" ||
+		entry.Category == nil || entry.Category.Term != "Blog" || !strings.HasPrefix(entry.ID, "urn:uuid:") {
+		t.Errorf("the first Atom entry is %+v", entry)
+	}
+	if _, err := time.Parse(time.RFC3339, entry.Updated); err != nil {
+		t.Errorf("updated: %v", err)
+	}
+
+	if _, err := runCommand(t, s, "", "export", "feed", "--format", "json"); err == nil {
+		t.Error("exporting a json feed succeeded")
+	}
+}
+
+func TestExportFeedHTTP(t *testing.T) {
+	s, server := newAPIServer(t)
+	feedURL := newFeedServer(t, rssItem{"Blog post", time.Now()}).URL + "/feed.xml"
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", feedURL)
+	mustRun(t, s, "", "agg", "--once")
+
+	api := apiClient{t: t, url: server.URL}
+	api.do("POST", "/api/users", map[string]string{"name": "bob", "password": "correct horse"}, nil)
+	var session struct{ Token string }
+	api.do("POST", "/api/sessions", map[string]string{"name": "bob", "password": "correct horse"}, &session)
+	bob := api.as("Authorization", "Bearer "+session.Token)
+
+	get := func(client apiClient, path string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, values := range client.header {
+			req.Header[name] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	resp, body := get(api, "/export/alice/rss")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("GET /export/alice/rss returned %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if got := rssTitles(t, body); !slices.Equal(got, []string{"Blog post"}) {
+		t.Errorf("alice's feed has %q", got)
+	}
+	if resp, _ := get(api, "/export/alice/atom"); !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/atom+xml") {
+		t.Errorf("the Atom feed is served as %s", resp.Header.Get("Content-Type"))
+	}
+
+	// Users with a password need their own session token, in the header or
+	// the query.
+	for path, want := range map[string]int{
+		"/export/bob/rss":                          http.StatusUnauthorized,
+		"/export/bob/rss?token=" + session.Token:   http.StatusOK,
+		"/export/bob/rss?token=not-a-token":        http.StatusUnauthorized,
+		"/export/alice/json":                       http.StatusNotFound,
+		"/export/nobody/rss":                       http.StatusNotFound,
+		"/export/alice/rss?limit=0":                http.StatusBadRequest,
+		"/export/alice/rss?limit=1000":             http.StatusBadRequest,
+		"/export/alice/rss?folder=none&q=anything": http.StatusOK,
+	} {
+		if resp, _ := get(api, path); resp.StatusCode != want {
+			t.Errorf("GET %s returned %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+	if resp, _ := get(bob, "/export/bob/atom"); resp.StatusCode != http.StatusOK {
+		t.Errorf("bob's token in the header returned %d", resp.StatusCode)
+	}
+}
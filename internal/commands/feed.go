@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// checkFeedOwner returns an error unless the user added the feed or is an
+// admin.
+func checkFeedOwner(user database.User, feedUserID uuid.NullUUID) error {
+	if user.Role == roleAdmin {
+		return nil
+	}
+	if feedUserID.Valid && feedUserID.UUID == user.ID {
+		return nil
+	}
+	return fmt.Errorf("only the user who added the feed or an admin can change it")
+}
+
+func getFeed(s *state, url string) (database.Feed, error) {
+	feed, err := s.db.GetFeedByUrl(s.ctx, sqlString(url))
+	if errors.Is(err, sql.ErrNoRows) {
+		return feed, fmt.Errorf("feed %s not found", url)
+	}
+	if err != nil {
+		return feed, fmt.Errorf("failed to get feed: %v", err)
+	}
+	return feed, nil
+}
+
+func handlerFeedRm(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("feed rm", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("feed rm: %v", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("feed rm command expected a feed url")
+	}
+
+	ctx := s.ctx
+	feed, err := s.db.GetFeedStats(ctx, sqlString(flags.Arg(0)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("feed %s not found", flags.Arg(0))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feed: %v", err)
+	}
+	if err := checkFeedOwner(user, feed.UserID); err != nil {
+		return err
+	}
+
+	fmt.Printf("This will delete feed %s with %d posts, followed by %d users.\n", feed.Name.String, feed.Posts, feed.Followers)
+	if err := confirm(*yes); err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteFeed(ctx, feed.ID); err != nil {
+		return fmt.Errorf("failed to delete feed: %v", err)
+	}
+	fmt.Printf("Feed %s has been deleted\n", feed.Name.String)
+	return nil
+}
+
+func handlerFeedRename(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("feed rename command expected a feed url and a name")
+	}
+
+	feed, err := getFeed(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	if err := checkFeedOwner(user, feed.UserID); err != nil {
+		return err
+	}
+
+	err = s.db.RenameFeed(s.ctx, database.RenameFeedParams{
+		Name:      sqlString(cmd.arguments[1]),
+		UpdatedAt: sqlCurrentTime(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename feed: %v", err)
+	}
+	fmt.Printf("Feed %s has been renamed to %s\n", feed.Name.String, cmd.arguments[1])
+	return nil
+}
+
+func handlerFeedSetURL(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("feed set-url command expected the old and the new url")
+	}
+	oldURL, newURL := cmd.arguments[0], cmd.arguments[1]
+
+	feed, err := getFeed(s, oldURL)
+	if err != nil {
+		return err
+	}
+	if err := checkFeedOwner(user, feed.UserID); err != nil {
+		return err
+	}
+	if _, err := getFeed(s, newURL); err == nil {
+		return fmt.Errorf("feed %s already exists", newURL)
+	}
+
+	err = s.db.SetFeedUrl(s.ctx, database.SetFeedUrlParams{
+		Url:       sqlString(newURL),
+		UpdatedAt: sqlCurrentTime(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change feed url: %v", err)
+	}
+	fmt.Printf("Feed %s now uses %s\n", feed.Name.String, newURL)
+	return nil
+}
+
+func handlerFeedShow(s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("feed show command expected a feed url")
+	}
+
+	feed, err := s.db.GetFeedStats(s.ctx, sqlString(cmd.arguments[0]))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("feed %s not found", cmd.arguments[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feed: %v", err)
+	}
+
+	return s.render([]database.GetFeedStatsRow{feed}, func() {
+		lastFetch := "never"
+		if feed.LastFetchedAt.Valid {
+			lastFetch = feed.LastFetchedAt.Time.Format("2006-01-02 15:04")
+		}
+		status := "ok"
+		if feed.LastFetchError.Valid {
+			status = "error: " + feed.LastFetchError.String
+		} else if !feed.LastFetchedAt.Valid {
+			status = "not fetched yet"
+		}
+
+		fmt.Printf("Name:       %s\n", feed.Name.String)
+		fmt.Printf("URL:        %s\n", feed.Url.String)
+		fmt.Printf("Added by:   %s\n", feed.OwnerName.String)
+		fmt.Printf("Added at:   %s\n", feed.CreatedAt.Time.Format("2006-01-02 15:04"))
+		fmt.Printf("Followers:  %d\n", feed.Followers)
+		fmt.Printf("Posts:      %d\n", feed.Posts)
+		fmt.Printf("Last fetch: %s\n", lastFetch)
+		fmt.Printf("Status:     %s\n", status)
+	})
+}
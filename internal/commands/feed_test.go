@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedShow(t *testing.T) {
+	s := newTestState(t)
+	server := newFeedServer(t, rssItem{"Blog post", time.Now()})
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", server.URL+"/feed.xml")
+	mustRun(t, s, "", "addfeed", "Broken", server.URL+"/missing.xml")
+
+	output := mustRun(t, s, "", "feed", "show", server.URL+"/feed.xml")
+	if !strings.Contains(output, "Status:     not fetched yet") || !strings.Contains(output, "Last fetch: never") {
+		t.Errorf("feed show before agg printed %q", output)
+	}
+
+	mustRun(t, s, "", "agg", "--once")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "follow", server.URL+"/feed.xml")
+
+	output = mustRun(t, s, "", "feed", "show", server.URL+"/feed.xml")
+	for _, want := range []string{
+		"Name:       Blog\n",
+		"Added by:   alice\n",
+		"Followers:  2\n",
+		"Posts:      1\n",
+		"Status:     ok\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("feed show printed %q, want %q", output, want)
+		}
+	}
+	if output := mustRun(t, s, "", "feed", "show", server.URL+"/missing.xml"); !strings.Contains(output, "Status:     error: ") {
+		t.Errorf("feed show of the broken feed printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "feed", "show", "https://example.com/none.xml"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("feed show of an unknown feed: %v", err)
+	}
+}
+
+func TestFeedChangesNeedOwner(t *testing.T) {
+	s := newTestState(t)
+	const blogURL, newURL = "https://example.com/feed.xml", "https://example.com/blog.xml"
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "addfeed", "Blog", blogURL)
+	mustRun(t, s, "", "register", "carol")
+	mustRun(t, s, "", "addfeed", "News", "https://example.com/news.xml")
+
+	// carol, a member, didn't add Blog.
+	for _, args := range [][]string{
+		{"rm", "--yes", blogURL},
+		{"rename", blogURL, "Mine"},
+		{"set-url", blogURL, newURL},
+	} {
+		_, err := runCommand(t, s, "", "feed", args...)
+		if err == nil || !strings.Contains(err.Error(), "only the user who added the feed") {
+			t.Errorf("carol ran feed %s: %v", args[0], err)
+		}
+	}
+
+	mustRun(t, s, "", "login", "bob")
+	if output := mustRun(t, s, "", "feed", "rename", blogURL, "My blog"); output != "Feed Blog has been renamed to My blog\n" {
+		t.Errorf("feed rename printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "feed", "set-url", blogURL, "https://example.com/news.xml"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("moving Blog onto News: %v", err)
+	}
+	mustRun(t, s, "", "feed", "set-url", blogURL, newURL)
+	if _, err := getFeed(s, blogURL); err == nil {
+		t.Error("the old url still finds the feed")
+	}
+	if output := mustRun(t, s, "", "following"); output != "My blog\n" {
+		t.Errorf("bob follows %q after set-url", output)
+	}
+
+	// alice is an admin and can remove any feed.
+	mustRun(t, s, "", "login", "alice")
+	output, err := runCommand(t, s, "", "feed", "rm", newURL)
+	if err == nil {
+		t.Error("feed rm without --yes succeeded")
+	}
+	if !strings.Contains(output, "This will delete feed My blog with 0 posts, followed by 1 users.") {
+		t.Errorf("feed rm printed %q", output)
+	}
+	mustRun(t, s, "", "feed", "rm", "--yes", newURL)
+	mustRun(t, s, "", "login", "bob")
+	if output := mustRun(t, s, "", "following"); output != "" {
+		t.Errorf("bob follows %q after the feed was removed", output)
+	}
+}
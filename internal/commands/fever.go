@@ -0,0 +1,411 @@
+package commands
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// The Fever API (https://feedafever.com/api) is spoken by mobile readers
+// such as Reeder, ReadKit and FeedMe. Items are identified by posts.seq.
+// Feeds and groups have no integer ids of their own, so they are identified
+// by a hash of the feed id or folder name.
+const (
+	feverAPIVersion = 3
+	feverPageSize   = 50
+)
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	Url               string `json:"url"`
+	SiteUrl           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Html          string `json:"html"`
+	Url           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// handlerFever sets the password Fever clients log in with. It is asked
+// for rather than taken as an argument, so it doesn't end up in the shell
+// history. An empty password turns the Fever API off for the user.
+func handlerFever(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 0 {
+		return fmt.Errorf("fever command takes no arguments, it asks for the password")
+	}
+
+	password, err := readNewPassword("Fever password (leave empty to turn the Fever API off): ")
+	if err != nil {
+		return err
+	}
+	feverKey := sql.NullString{}
+	if password != "" {
+		feverKey = sqlString(feverAPIKey(user.Name, password))
+	}
+
+	err = s.db.SetUserFeverKey(s.ctx, database.SetUserFeverKeyParams{
+		FeverKey:  feverKey,
+		UpdatedAt: sqlCurrentTime(),
+		ID:        user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set fever password: %v", err)
+	}
+
+	if password == "" {
+		fmt.Printf("Fever API turned off for %s\n", user.Name)
+		return nil
+	}
+	fmt.Printf("Fever API enabled for %s. Log in with %s and your password at http://<host>/fever/\n", user.Name, user.Name)
+	return nil
+}
+
+// feverAPIKey is the key the clients send, md5("email:password").
+func feverAPIKey(name, password string) string {
+	sum := md5.Sum([]byte(name + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func feverID(s string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	id := int64(h.Sum32() & 0x7fffffff)
+	if id == 0 {
+		// 0 is the "Kindling" super group.
+		id = 1
+	}
+	return id
+}
+
+func (api *apiServer) fever(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeFever(w, resp)
+		return
+	}
+
+	user, err := api.s.db.GetUserByFeverKey(r.Context(), sqlString(strings.ToLower(r.FormValue("api_key"))))
+	if err != nil {
+		writeFever(w, resp)
+		return
+	}
+	resp["auth"] = 1
+
+	if err := api.feverRespond(r, user, resp); err != nil {
+		resp["error"] = err.Error()
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	writeFever(w, resp)
+}
+
+func writeFever(w http.ResponseWriter, resp map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (api *apiServer) feverRespond(r *http.Request, user database.User, resp map[string]any) error {
+	ctx := r.Context()
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
+	_, wantGroups := r.Form["groups"]
+	_, wantFeeds := r.Form["feeds"]
+
+	if mark := r.FormValue("mark"); mark != "" {
+		if err := api.feverMark(r, user, mark); err != nil {
+			return err
+		}
+	}
+
+	feeds, err := api.s.db.GetFeverFeedsForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var lastRefreshed time.Time
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Time.After(lastRefreshed) {
+			lastRefreshed = feed.LastFetchedAt.Time
+		}
+	}
+	resp["last_refreshed_on_time"] = unixTime(lastRefreshed)
+
+	if wantGroups || wantFeeds {
+		groupFeeds := map[string][]string{}
+		for _, feed := range feeds {
+			if feed.Folder.Valid {
+				groupFeeds[feed.Folder.String] = append(groupFeeds[feed.Folder.String], strconv.FormatInt(feverID(feed.ID.String()), 10))
+			}
+		}
+
+		feedsGroups := []feverFeedsGroup{}
+		groups := []feverGroup{}
+		for _, folder := range sortedKeys(groupFeeds) {
+			groups = append(groups, feverGroup{ID: feverID(folder), Title: folder})
+			feedsGroups = append(feedsGroups, feverFeedsGroup{
+				GroupID: feverID(folder),
+				FeedIDs: strings.Join(groupFeeds[folder], ","),
+			})
+		}
+		if wantGroups {
+			resp["groups"] = groups
+		}
+		resp["feeds_groups"] = feedsGroups
+	}
+
+	if wantFeeds {
+		list := []feverFeed{}
+		for _, feed := range feeds {
+			list = append(list, feverFeed{
+				ID:                feverID(feed.ID.String()),
+				Title:             feed.Name.String,
+				Url:               feed.Url.String,
+				SiteUrl:           feed.Url.String,
+				LastUpdatedOnTime: unixTime(feed.LastFetchedAt.Time),
+			})
+		}
+		resp["feeds"] = list
+	}
+
+	if _, ok := r.Form["favicons"]; ok {
+		resp["favicons"] = []any{}
+	}
+	if _, ok := r.Form["links"]; ok {
+		resp["links"] = []any{}
+	}
+
+	if _, ok := r.Form["items"]; ok {
+		items, err := api.feverItems(r, userID)
+		if err != nil {
+			return err
+		}
+		total, err := api.s.db.CountPostsForUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		resp["items"] = items
+		resp["total_items"] = total
+	}
+
+	if _, ok := r.Form["unread_item_ids"]; ok {
+		seqs, err := api.s.db.GetUnreadPostSeqsForUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		resp["unread_item_ids"] = joinInts(seqs)
+	}
+	if _, ok := r.Form["saved_item_ids"]; ok {
+		seqs, err := api.s.db.GetStarredPostSeqsForUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		resp["saved_item_ids"] = joinInts(seqs)
+	}
+	return nil
+}
+
+// feverItems pages with since_id (ascending) or max_id (descending), or
+// returns the items listed in with_ids.
+func (api *apiServer) feverItems(r *http.Request, userID uuid.NullUUID) ([]feverItem, error) {
+	ctx := r.Context()
+	var rows []database.GetFeverItemsSinceRow
+
+	switch {
+	case r.FormValue("with_ids") != "":
+		for _, id := range strings.Split(r.FormValue("with_ids"), ",") {
+			seq, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				continue
+			}
+			row, err := api.s.db.GetFeverItem(ctx, database.GetFeverItemParams{UserID: userID, Seq: seq})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, database.GetFeverItemsSinceRow(row))
+			if len(rows) == feverPageSize {
+				break
+			}
+		}
+	case r.FormValue("max_id") != "":
+		maxID, _ := strconv.ParseInt(r.FormValue("max_id"), 10, 64)
+		before, err := api.s.db.GetFeverItemsBefore(ctx, database.GetFeverItemsBeforeParams{
+			UserID: userID,
+			Seq:    maxID,
+			Limit:  feverPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range before {
+			rows = append(rows, database.GetFeverItemsSinceRow(row))
+		}
+	default:
+		sinceID, _ := strconv.ParseInt(r.FormValue("since_id"), 10, 64)
+		var err error
+		rows, err = api.s.db.GetFeverItemsSince(ctx, database.GetFeverItemsSinceParams{
+			UserID: userID,
+			Seq:    sinceID,
+			Limit:  feverPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := []feverItem{}
+	for _, row := range rows {
+		item := feverItem{
+			ID:            row.Seq,
+			FeedID:        feverID(row.FeedID.UUID.String()),
+			Title:         row.Title.String,
+			Html:          row.Description.String,
+			Url:           row.Url.String,
+			CreatedOnTime: unixTime(row.PublishedAt.Time),
+		}
+		if row.ReadAt.Valid {
+			item.IsRead = 1
+		}
+		if row.StarredAt.Valid {
+			item.IsSaved = 1
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (api *apiServer) feverMark(r *http.Request, user database.User, mark string) error {
+	ctx := r.Context()
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
+	as := r.FormValue("as")
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id: %v", err)
+	}
+
+	if mark == "item" {
+		post, err := api.s.db.GetFeverItem(ctx, database.GetFeverItemParams{UserID: userID, Seq: id})
+		if err != nil {
+			return err
+		}
+
+		switch as {
+		case "read", "unread":
+			readAt := sql.NullTime{}
+			if as == "read" {
+				readAt = sqlCurrentTime()
+			}
+			return api.s.db.SetPostRead(ctx, database.SetPostReadParams{UserID: user.ID, PostID: post.ID, ReadAt: readAt})
+		case "saved", "unsaved":
+			starredAt := sql.NullTime{}
+			if as == "saved" {
+				starredAt = sqlCurrentTime()
+			}
+			return api.s.db.SetPostStarred(ctx, database.SetPostStarredParams{UserID: user.ID, PostID: post.ID, StarredAt: starredAt})
+		}
+		return fmt.Errorf("can not mark an item as %q", as)
+	}
+
+	if as != "read" {
+		return fmt.Errorf("can not mark a %s as %q", mark, as)
+	}
+
+	params := database.GetUnreadPostIDsBeforeParams{
+		UserID: userID,
+		Before: sqlCurrentTime(),
+	}
+	if before, err := strconv.ParseInt(r.FormValue("before"), 10, 64); err == nil && before > 0 {
+		params.Before = sqlTime(time.Unix(before, 0))
+	}
+
+	feeds, err := api.s.db.GetFeverFeedsForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch mark {
+	case "feed":
+		for _, feed := range feeds {
+			if feverID(feed.ID.String()) == id {
+				params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+			}
+		}
+		if !params.FeedID.Valid {
+			return fmt.Errorf("unknown feed %d", id)
+		}
+	case "group":
+		// Group 0 is every feed.
+		for _, feed := range feeds {
+			if id != 0 && feed.Folder.Valid && feverID(feed.Folder.String) == id {
+				params.Folder = feed.Folder
+			}
+		}
+		if id != 0 && !params.Folder.Valid {
+			return fmt.Errorf("unknown group %d", id)
+		}
+	default:
+		return fmt.Errorf("can not mark %q", mark)
+	}
+
+	ids, err := api.s.db.GetUnreadPostIDsBefore(ctx, params)
+	if err != nil {
+		return err
+	}
+	for _, postID := range ids {
+		err := api.s.db.SetPostRead(ctx, database.SetPostReadParams{UserID: user.ID, PostID: postID, ReadAt: sqlCurrentTime()})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func joinInts(ints []int64) string {
+	parts := make([]string, len(ints))
+	for i, n := range ints {
+		parts[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// feverResponse is the part of a Fever API response the tests look at.
+type feverResponse struct {
+	APIVersion    int               `json:"api_version"`
+	Auth          int               `json:"auth"`
+	Error         string            `json:"error"`
+	Groups        []feverGroup      `json:"groups"`
+	FeedsGroups   []feverFeedsGroup `json:"feeds_groups"`
+	Feeds         []feverFeed       `json:"feeds"`
+	Items         []feverItem       `json:"items"`
+	TotalItems    int64             `json:"total_items"`
+	UnreadItemIDs string            `json:"unread_item_ids"`
+	SavedItemIDs  string            `json:"saved_item_ids"`
+}
+
+// feverClient calls the Fever API of a test server the way the apps do,
+// with the API key in the body and the request in the query string.
+type feverClient struct {
+	t      *testing.T
+	url    string
+	apiKey string
+}
+
+func (c feverClient) call(query string, form url.Values) feverResponse {
+	c.t.Helper()
+	if form == nil {
+		form = url.Values{}
+	}
+	form.Set("api_key", c.apiKey)
+	resp, err := http.PostForm(c.url+"/fever/?api&"+query, form)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body feverResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		c.t.Fatalf("%s: %v", query, err)
+	}
+	if body.Error != "" {
+		c.t.Fatalf("%s: %s", query, body.Error)
+	}
+	return body
+}
+
+// newFeverServer registers alice with the Fever password "secret" and
+// fetches a feed of 60 posts in the News folder and a feed of 3 posts
+// without one.
+func newFeverServer(t *testing.T) (*state, feverClient) {
+	t.Helper()
+	s := newTestState(t)
+
+	now := time.Now()
+	news := []rssItem{}
+	for i := range 60 {
+		news = append(news, rssItem{fmt.Sprintf("News %d", i), now.Add(-time.Duration(60-i) * time.Minute)})
+	}
+	newsServer := newFeedServer(t, news...)
+	blogServer := newFeedServer(t,
+		rssItem{"Blog 0", now.Add(-3 * time.Hour)},
+		rssItem{"Blog 1", now.Add(-2 * time.Hour)},
+		rssItem{"Blog 2", now.Add(-time.Hour)},
+	)
+
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "News", newsServer.URL+"/feed.xml")
+	mustRun(t, s, "", "folder", newsServer.URL+"/feed.xml", "News")
+	mustRun(t, s, "", "addfeed", "Blog", blogServer.URL+"/feed.xml")
+	mustRun(t, s, "", "agg", "--once")
+	mustRun(t, s, "secret\n", "fever")
+
+	api := &apiServer{s: s}
+	server := httptest.NewServer(api.routes())
+	t.Cleanup(server.Close)
+	return s, feverClient{t: t, url: server.URL, apiKey: feverAPIKey("alice", "secret")}
+}
+
+func itemIDs(items []feverItem) []int64 {
+	ids := []int64{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func splitIDs(t *testing.T, s string) []int64 {
+	t.Helper()
+	ids := []int64{}
+	if s == "" {
+		return ids
+	}
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestFeverAuth(t *testing.T) {
+	_, client := newFeverServer(t)
+
+	resp := client.call("", nil)
+	if resp.APIVersion != feverAPIVersion || resp.Auth != 1 {
+		t.Errorf("with the right key: api_version %d, auth %d", resp.APIVersion, resp.Auth)
+	}
+
+	wrong := client
+	wrong.apiKey = feverAPIKey("alice", "wrong")
+	resp = wrong.call("items", nil)
+	if resp.Auth != 0 {
+		t.Error("a wrong key is authenticated")
+	}
+	if resp.Items != nil {
+		t.Error("a wrong key got items")
+	}
+
+	// Clients may send the key in upper case.
+	upper := client
+	upper.apiKey = strings.ToUpper(client.apiKey)
+	if resp := upper.call("", nil); resp.Auth != 1 {
+		t.Error("an upper case key is not authenticated")
+	}
+}
+
+func TestFeverPassword(t *testing.T) {
+	s, client := newFeverServer(t)
+
+	if _, err := runCommand(t, s, "", "fever", "secret"); err == nil {
+		t.Error("fever took the password as an argument")
+	}
+
+	mustRun(t, s, "other\n", "fever")
+	if resp := client.call("", nil); resp.Auth != 0 {
+		t.Error("the old Fever password still works")
+	}
+	client.apiKey = feverAPIKey("alice", "other")
+	if resp := client.call("", nil); resp.Auth != 1 {
+		t.Error("the new Fever password doesn't work")
+	}
+
+	output := mustRun(t, s, "", "fever")
+	if !strings.Contains(output, "Fever API turned off") {
+		t.Errorf("fever with an empty password printed %q", output)
+	}
+	if resp := client.call("", nil); resp.Auth != 0 {
+		t.Error("the Fever API still works after turning it off")
+	}
+}
+
+func TestFeverGroupsAndFeeds(t *testing.T) {
+	_, client := newFeverServer(t)
+
+	resp := client.call("groups&feeds", nil)
+	if len(resp.Groups) != 1 || resp.Groups[0].Title != "News" || resp.Groups[0].ID != feverID("News") {
+		t.Fatalf("groups = %+v, want only News", resp.Groups)
+	}
+	if len(resp.Feeds) != 2 {
+		t.Fatalf("feeds = %+v, want News and Blog", resp.Feeds)
+	}
+
+	feedIDs := map[string]int64{}
+	for _, feed := range resp.Feeds {
+		feedIDs[feed.Title] = feed.ID
+		if feed.LastUpdatedOnTime == 0 {
+			t.Errorf("feed %s has no last_updated_on_time", feed.Title)
+		}
+	}
+	want := []feverFeedsGroup{{GroupID: feverID("News"), FeedIDs: strconv.FormatInt(feedIDs["News"], 10)}}
+	if !slices.Equal(resp.FeedsGroups, want) {
+		t.Errorf("feeds_groups = %+v, want %+v", resp.FeedsGroups, want)
+	}
+
+	resp = client.call("groups", nil)
+	if resp.Feeds != nil || len(resp.FeedsGroups) != 1 {
+		t.Errorf("groups alone returned feeds %v and feeds_groups %v", resp.Feeds, resp.FeedsGroups)
+	}
+}
+
+func TestFeverItems(t *testing.T) {
+	_, client := newFeverServer(t)
+
+	// since_id pages up from the oldest item.
+	first := client.call("items", nil)
+	if first.TotalItems != 63 {
+		t.Errorf("total_items = %d, want 63", first.TotalItems)
+	}
+	if len(first.Items) != feverPageSize {
+		t.Fatalf("first page has %d items, want %d", len(first.Items), feverPageSize)
+	}
+	ids := itemIDs(first.Items)
+	if !slices.IsSorted(ids) {
+		t.Errorf("since_id page is not ascending: %v", ids)
+	}
+
+	next := client.call("items", url.Values{"since_id": {strconv.FormatInt(ids[len(ids)-1], 10)}})
+	if len(next.Items) != 13 {
+		t.Fatalf("second page has %d items, want 13", len(next.Items))
+	}
+	all := append(ids, itemIDs(next.Items)...)
+	if next.Items[0].ID <= ids[len(ids)-1] {
+		t.Errorf("second page starts at %d, after %d", next.Items[0].ID, ids[len(ids)-1])
+	}
+	if last := client.call("items", url.Values{"since_id": {strconv.FormatInt(all[len(all)-1], 10)}}); len(last.Items) != 0 {
+		t.Errorf("since the newest item returned %d items", len(last.Items))
+	}
+
+	// max_id pages down from the newest item.
+	before := client.call("items", url.Values{"max_id": {strconv.FormatInt(all[len(all)-1], 10)}})
+	beforeIDs := itemIDs(before.Items)
+	if len(beforeIDs) != feverPageSize {
+		t.Fatalf("max_id page has %d items, want %d", len(beforeIDs), feverPageSize)
+	}
+	if beforeIDs[0] != all[len(all)-2] {
+		t.Errorf("max_id page starts at %d, want %d", beforeIDs[0], all[len(all)-2])
+	}
+	if !slices.IsSortedFunc(beforeIDs, func(a, b int64) int { return int(b - a) }) {
+		t.Errorf("max_id page is not descending: %v", beforeIDs)
+	}
+
+	// with_ids returns the listed items and skips unknown ones.
+	withIDs := fmt.Sprintf("%d,%d,999999", all[3], all[60])
+	resp := client.call("items", url.Values{"with_ids": {withIDs}})
+	if got := itemIDs(resp.Items); !slices.Equal(got, []int64{all[3], all[60]}) {
+		t.Errorf("with_ids %s returned %v", withIDs, got)
+	}
+	for _, item := range resp.Items {
+		if item.Title == "" || item.Url == "" || item.FeedID == 0 || item.CreatedOnTime == 0 {
+			t.Errorf("item %+v is missing fields", item)
+		}
+	}
+}
+
+func TestFeverMarkItem(t *testing.T) {
+	_, client := newFeverServer(t)
+	items := client.call("items", nil).Items
+	id := strconv.FormatInt(items[0].ID, 10)
+
+	resp := client.call("unread_item_ids&saved_item_ids", url.Values{"mark": {"item"}, "as": {"read"}, "id": {id}})
+	unread := splitIDs(t, resp.UnreadItemIDs)
+	if len(unread) != 62 || slices.Contains(unread, items[0].ID) {
+		t.Errorf("after marking %s read %d items are unread", id, len(unread))
+	}
+
+	resp = client.call("saved_item_ids", url.Values{"mark": {"item"}, "as": {"saved"}, "id": {id}})
+	if resp.SavedItemIDs != id {
+		t.Errorf("saved_item_ids = %q, want %s", resp.SavedItemIDs, id)
+	}
+	item := client.call("items", url.Values{"with_ids": {id}}).Items[0]
+	if item.IsRead != 1 || item.IsSaved != 1 {
+		t.Errorf("item %s is_read %d, is_saved %d", id, item.IsRead, item.IsSaved)
+	}
+
+	client.call("", url.Values{"mark": {"item"}, "as": {"unread"}, "id": {id}})
+	resp = client.call("unread_item_ids&saved_item_ids", url.Values{"mark": {"item"}, "as": {"unsaved"}, "id": {id}})
+	if len(splitIDs(t, resp.UnreadItemIDs)) != 63 || resp.SavedItemIDs != "" {
+		t.Errorf("after marking %s unread and unsaved: unread %q, saved %q", id, resp.UnreadItemIDs, resp.SavedItemIDs)
+	}
+}
+
+func TestFeverMarkFeedAndGroup(t *testing.T) {
+	_, client := newFeverServer(t)
+	feeds := client.call("feeds", nil).Feeds
+	before := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+
+	var blogID int64
+	for _, feed := range feeds {
+		if feed.Title == "Blog" {
+			blogID = feed.ID
+		}
+	}
+	resp := client.call("unread_item_ids", url.Values{"mark": {"feed"}, "as": {"read"}, "id": {strconv.FormatInt(blogID, 10)}, "before": {before}})
+	if unread := splitIDs(t, resp.UnreadItemIDs); len(unread) != 60 {
+		t.Errorf("after marking the blog read %d items are unread, want 60", len(unread))
+	}
+
+	resp = client.call("unread_item_ids", url.Values{"mark": {"group"}, "as": {"read"}, "id": {strconv.FormatInt(feverID("News"), 10)}, "before": {before}})
+	if resp.UnreadItemIDs != "" {
+		t.Errorf("after marking the News group read unread_item_ids = %q", resp.UnreadItemIDs)
+	}
+
+	// before leaves items fetched after it unread.
+	item := client.call("items", nil).Items[0]
+	client.call("", url.Values{"mark": {"item"}, "as": {"unread"}, "id": {strconv.FormatInt(item.ID, 10)}})
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	resp = client.call("unread_item_ids", url.Values{"mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {old}})
+	if resp.UnreadItemIDs != strconv.FormatInt(item.ID, 10) {
+		t.Errorf("group 0 before an hour ago marked items fetched now: unread_item_ids = %q", resp.UnreadItemIDs)
+	}
+	resp = client.call("unread_item_ids", url.Values{"mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {before}})
+	if resp.UnreadItemIDs != "" {
+		t.Errorf("after marking group 0 read unread_item_ids = %q", resp.UnreadItemIDs)
+	}
+}
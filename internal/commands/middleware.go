@@ -1,14 +1,14 @@
 package commands
 
 import (
-	"context"
+	"fmt"
 
 	"github.com/babanini95/gatorcli/internal/database"
 )
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, c command) error {
-		user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
+		user, err := currentUser(s)
 		if err != nil {
 			return err
 		}
@@ -16,3 +16,14 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 		return handler(s, c, user)
 	}
 }
+
+// middlewareAdmin is middlewareLoggedIn for commands only admins may run.
+func middlewareAdmin(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+	return middlewareLoggedIn(func(s *state, c command, user database.User) error {
+		if user.Role != roleAdmin {
+			return fmt.Errorf("only admins can run this command")
+		}
+
+		return handler(s, c, user)
+	})
+}
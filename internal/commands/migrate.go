@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/babanini95/gatorcli/internal/migrate"
+)
+
+// noSchemaCommands don't touch the database, or fix its schema, so they
+// run without the schema version check.
+var noSchemaCommands = map[string]bool{
+	"migrate":    true,
+	"config":     true,
+	"profile":    true,
+	"completion": true,
+	"__complete": true,
+}
+
+func migrator(s *state) (*migrate.Migrator, error) {
+	if s.conn == nil {
+		return nil, fmt.Errorf("the %s store needs no migrations", s.driver)
+	}
+	return migrate.New(s.conn, s.driver)
+}
+
+// checkSchema refuses to run commands against a database that isn't at the
+// schema version this gator was built for.
+func checkSchema(s *state) error {
+	if s.conn == nil {
+		return nil
+	}
+	m, err := migrator(s)
+	if err != nil {
+		return err
+	}
+	version, err := m.Version(s.ctx)
+	if err != nil {
+		return fmt.Errorf("can not check the database schema: %v", err)
+	}
+
+	switch latest := m.Latest(); {
+	case version == 0:
+		return fmt.Errorf("the database has no gator tables yet, create them with: gator migrate up")
+	case version < latest:
+		return fmt.Errorf("the database schema is at version %d but gator needs version %d, upgrade it with: gator migrate up", version, latest)
+	case version > latest:
+		return fmt.Errorf("the database schema is at version %d, newer than the version %d this gator supports; upgrade gator", version, latest)
+	}
+	return nil
+}
+
+func handlerMigrateUp(s *state, cmd command) error {
+	m, err := migrator(s)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up(s.ctx)
+	for _, migration := range applied {
+		fmt.Printf("Applied %s\n", migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("The database schema is up to date at version %d\n", m.Latest())
+	}
+	return nil
+}
+
+func handlerMigrateDown(s *state, cmd command) error {
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("migrate down: %v", err)
+	}
+
+	m, err := migrator(s)
+	if err != nil {
+		return err
+	}
+	version, err := m.Version(s.ctx)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("no migrations to roll back")
+	}
+
+	fmt.Printf("This will roll back migration %d, which may delete data.\n", version)
+	if err := confirm(*yes); err != nil {
+		return err
+	}
+
+	migration, err := m.Down(s.ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rolled back %s\n", migration.Name)
+	return nil
+}
+
+func handlerMigrateStatus(s *state, cmd command) error {
+	m, err := migrator(s)
+	if err != nil {
+		return err
+	}
+	statuses, err := m.Status(s.ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.render(statuses, func() {
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied"
+				if status.AppliedAt.Valid {
+					applied += " " + status.AppliedAt.Time.Format("2006-01-02 15:04")
+				}
+			}
+			fmt.Printf("%-30s %s\n", status.Name, applied)
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+	outputTable  = "table"
+)
+
+// field is a single named value of a rendered record.
+type field struct {
+	name  string
+	value any
+}
+
+// render prints rows, a slice of structs, with the template chosen with
+// --template or in the format chosen with --output. The text format keeps
+// each command's own layout, so text is called instead.
+func (s *state) render(rows any, text func()) error {
+	if s.template != "" {
+		return s.renderTemplate(rows)
+	}
+	output := s.cfg.Output()
+	if output == "" || output == outputText {
+		text()
+		return nil
+	}
+
+	records := toRecords(rows)
+	switch output {
+	case outputJSON:
+		return renderJSON(os.Stdout, records)
+	case outputNDJSON:
+		return renderNDJSON(os.Stdout, records)
+	case outputCSV:
+		return renderCSV(rows, records)
+	case outputTable:
+		return renderTable(rows, records)
+	}
+	return fmt.Errorf("unknown output format: %s", output)
+}
+
+func renderJSON(w io.Writer, records [][]field) error {
+	buf := bytes.Buffer{}
+	buf.WriteString("[")
+	for i, record := range records {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		if err := writeJSONObject(&buf, record); err != nil {
+			return err
+		}
+	}
+	if len(records) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func renderNDJSON(w io.Writer, records [][]field) error {
+	buf := bytes.Buffer{}
+	for _, record := range records {
+		if err := writeJSONObject(&buf, record); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeJSONObject keeps the fields in struct order, which encoding/json
+// can't do for maps.
+func writeJSONObject(buf *bytes.Buffer, record []field) error {
+	buf.WriteString("{")
+	for i, f := range record {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		key, err := json.Marshal(f.name)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return fmt.Errorf("can not encode %s: %v", f.name, err)
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+func renderCSV(rows any, records [][]field) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(fieldNames(rows)); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := w.Write(fieldStrings(record)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func renderTable(rows any, records [][]field) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := fieldNames(rows)
+	for i := range header {
+		header[i] = strings.ToUpper(header[i])
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, record := range records {
+		values := fieldStrings(record)
+		for i := range values {
+			values[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(values[i])
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
+
+func fieldStrings(record []field) []string {
+	values := make([]string, len(record))
+	for i, f := range record {
+		if f.value != nil {
+			values[i] = fmt.Sprint(f.value)
+		}
+	}
+	return values
+}
+
+// fieldNames returns the column names for a slice of structs, even when the
+// slice is empty.
+func fieldNames(rows any) []string {
+	t := reflect.TypeOf(rows).Elem()
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			names = append(names, snakeCase(t.Field(i).Name))
+		}
+	}
+	return names
+}
+
+func toRecords(rows any) [][]field {
+	v := reflect.ValueOf(rows)
+	records := make([][]field, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		records = append(records, toRecord(v.Index(i)))
+	}
+	return records
+}
+
+func toRecord(v reflect.Value) []field {
+	t := v.Type()
+	record := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		value := plainValue(v.Field(i).Interface())
+		if ts, ok := value.(time.Time); ok {
+			value = ts.Format(time.RFC3339)
+		}
+		record = append(record, field{
+			name:  snakeCase(t.Field(i).Name),
+			value: value,
+		})
+	}
+	return record
+}
+
+// plainValue unwraps the sql null types used by the database models so
+// they render as a value or null.
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case sql.NullString:
+		if !v.Valid {
+			return nil
+		}
+		return v.String
+	case sql.NullTime:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	case sql.NullInt32:
+		if !v.Valid {
+			return nil
+		}
+		return v.Int32
+	case sql.NullInt64:
+		if !v.Valid {
+			return nil
+		}
+		return v.Int64
+	case sql.NullBool:
+		if !v.Valid {
+			return nil
+		}
+		return v.Bool
+	case uuid.NullUUID:
+		if !v.Valid {
+			return nil
+		}
+		return v.UUID.String()
+	case uuid.UUID:
+		return v.String()
+	}
+	return v
+}
+
+// snakeCase turns a Go field name such as LastFetchedAt or UserID into
+// last_fetched_at or user_id.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	b := strings.Builder{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// globalFlags are the options shared by every command. They go between
+// "gator" and the command name.
+var globalFlags = map[string]func(s *state, value string) error{
+	"--output":   setOutput,
+	"-o":         setOutput,
+	"--template": setTemplate,
+	"--profile":  setProfile,
+	"--db-url":   setDbURL,
+	"--user":     setUser,
+	"--timeout":  setTimeout,
+}
+
+// parseGlobalFlags applies the global flags at the start of args to s and
+// returns the command with its arguments. Scanning stops at the command
+// name, so arguments such as "gator search -o" are left alone.
+func parseGlobalFlags(s *state, args []string) ([]string, error) {
+	rest := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		set, ok := globalFlags[name]
+		if !ok {
+			rest = args[i:]
+			break
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s expected a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		if err := set(s, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if output := s.cfg.Output(); output != "" && output != outputText && s.template != "" {
+		return nil, fmt.Errorf("--template can not be combined with --output %s", output)
+	}
+	return rest, nil
+}
+
+// setOutput is the --output global flag.
+func setOutput(s *state, format string) error {
+	return s.cfg.OverrideOutput(format)
+}
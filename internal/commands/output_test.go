@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOutputFormats(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	server := newFeedServer(t,
+		rssItem{"First post", now.Add(-2 * time.Hour)},
+		rssItem{"Second post", now.Add(-time.Hour)},
+	)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+	mustRun(t, s, "", "agg", "--once")
+
+	if err := setOutput(s, outputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var feeds []map[string]any
+	if err := json.Unmarshal([]byte(mustRun(t, s, "", "feeds")), &feeds); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{{"name": "Test", "url": server.URL + "/feed.xml", "user_name": "alice"}}
+	if len(feeds) != 1 || len(feeds[0]) != len(want[0]) {
+		t.Fatalf("feeds printed %v, want %v", feeds, want)
+	}
+	for key, value := range want[0] {
+		if feeds[0][key] != value {
+			t.Errorf("feeds printed %s %v, want %v", key, feeds[0][key], value)
+		}
+	}
+
+	var users []map[string]any
+	if err := json.Unmarshal([]byte(mustRun(t, s, "", "users")), &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0]["name"] != "alice" || users[0]["role"] != roleAdmin {
+		t.Errorf("users printed %v", users)
+	}
+	for key := range users[0] {
+		if !slices.Contains([]string{"id", "created_at", "updated_at", "name", "role"}, key) {
+			t.Errorf("users printed %s, which is not for everyone to see", key)
+		}
+	}
+
+	if err := setOutput(s, outputNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(mustRun(t, s, "", "browse", "10")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("browse printed %d lines, want a line per post", len(lines))
+	}
+	for _, line := range lines {
+		var post map[string]any
+		if err := json.Unmarshal([]byte(line), &post); err != nil {
+			t.Fatalf("browse printed %q: %v", line, err)
+		}
+		if _, err := time.Parse(time.RFC3339, post["published_at"].(string)); err != nil {
+			t.Errorf("published_at is not RFC 3339: %v", err)
+		}
+	}
+
+	if err := setOutput(s, outputCSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(mustRun(t, s, "", "following"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("following printed %d CSV records, want a header and a feed", len(records))
+	}
+	if url := slices.Index(records[0], "feed_url"); url < 0 || records[1][url] != server.URL+"/feed.xml" {
+		t.Errorf("following printed %q", records)
+	}
+
+	if err := setOutput(s, outputTable); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSpace(mustRun(t, s, "", "feeds")), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[0]), " ") != "NAME URL USER_NAME" {
+		t.Errorf("feeds printed the table %q", lines)
+	}
+}
+
+func TestOutputEmpty(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+
+	for format, want := range map[string]string{
+		outputJSON:   "[]\n",
+		outputNDJSON: "",
+		outputCSV:    "name,url,user_name\n",
+		outputTable:  "NAME  URL  USER_NAME\n",
+	} {
+		if err := setOutput(s, format); err != nil {
+			t.Fatal(err)
+		}
+		if output := mustRun(t, s, "", "feeds"); output != want {
+			t.Errorf("feeds --output %s printed %q, want %q", format, output, want)
+		}
+	}
+}
+
+func TestToRecord(t *testing.T) {
+	type row struct {
+		ID            uuid.UUID
+		FeedID        uuid.NullUUID
+		LastFetchedAt sql.NullTime
+		Title         sql.NullString
+		Count         sql.NullInt64
+		hidden        string
+	}
+	id := uuid.New()
+	fetched := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []row{{
+		ID:            id,
+		LastFetchedAt: sql.NullTime{Time: fetched, Valid: true},
+		Title:         sql.NullString{String: "Hello", Valid: true},
+		hidden:        "secret",
+	}}
+
+	if names := fieldNames(rows); !slices.Equal(names, []string{"id", "feed_id", "last_fetched_at", "title", "count"}) {
+		t.Errorf("fieldNames = %q", names)
+	}
+	var out strings.Builder
+	if err := renderNDJSON(&out, toRecords(rows)); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"` + id.String() + `","feed_id":null,"last_fetched_at":"2024-05-01T12:00:00Z","title":"Hello","count":null}` + "\n"
+	if out.String() != want {
+		t.Errorf("the row rendered as %s, want %s", out.String(), want)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Name":          "name",
+		"ID":            "id",
+		"UserID":        "user_id",
+		"FeedUrl":       "feed_url",
+		"LastFetchedAt": "last_fetched_at",
+		"URLPath":       "url_path",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestOutputAndTemplateConflict(t *testing.T) {
+	s := newTestState(t)
+	if _, err := parseGlobalFlags(s, []string{"--output", "json", "--template", "{{.Title}}", "browse"}); err == nil {
+		t.Error("--output json and --template were both accepted")
+	}
+
+	s = newTestState(t)
+	rest, err := parseGlobalFlags(s, []string{"-o=csv", "search", "-o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rest, []string{"search", "-o"}) || s.cfg.Output() != outputCSV {
+		t.Errorf("parseGlobalFlags left %q with output %s", rest, s.cfg.Output())
+	}
+	if _, err := parseGlobalFlags(s, []string{"--output", "yaml", "feeds"}); err == nil {
+		t.Error("--output yaml was accepted")
+	}
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/babanini95/gatorcli/internal/config"
+)
+
+type profileRecord struct {
+	Name            string
+	DbURL           string
+	CurrentUserName string
+	Active          bool
+}
+
+func handlerProfileList(s *state, cmd command) error {
+	records := []profileRecord{}
+	for _, name := range s.cfg.ProfileNames() {
+		p, _ := s.cfg.GetProfile(name)
+		if name == s.cfg.ActiveProfile() {
+			// Include the overrides from the environment and flags.
+			p = s.cfg.Profile
+		}
+		records = append(records, profileRecord{
+			Name:            name,
+			DbURL:           p.DbURL,
+			CurrentUserName: p.CurrentUserName,
+			Active:          name == s.cfg.ActiveProfile(),
+		})
+	}
+
+	return s.render(records, func() {
+		for _, p := range records {
+			mark := ""
+			if p.Active {
+				mark = " (current)"
+			}
+			fmt.Printf("* %s%s\n", p.Name, mark)
+			fmt.Printf("  db:   %s\n", p.DbURL)
+			if p.CurrentUserName != "" {
+				fmt.Printf("  user: %s\n", p.CurrentUserName)
+			}
+		}
+	})
+}
+
+func handlerProfileUse(s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("profile use command expected a profile name")
+	}
+
+	if err := s.cfg.SetCurrentProfile(cmd.arguments[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Now using profile %s\n", cmd.arguments[0])
+	return nil
+}
+
+func handlerProfileAdd(s *state, cmd command) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("profile add command expected a name and a database url")
+	}
+
+	err := s.cfg.AddProfile(cmd.arguments[0], config.Profile{DbURL: cmd.arguments[1]})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Profile %s has been added. Switch to it with: gator profile use %s\n", cmd.arguments[0], cmd.arguments[0])
+	return nil
+}
+
+// setProfile is the --profile global flag. It switches the profile for
+// this run only.
+func setProfile(s *state, name string) error {
+	if err := s.cfg.UseProfile(name); err != nil {
+		return err
+	}
+	return s.CreateQueries()
+}
+
+// setDbURL and setUser are the --db-url and --user global flags, which win
+// over the config file and the environment.
+func setDbURL(s *state, url string) error {
+	s.cfg.Override(config.Profile{DbURL: url})
+	return s.CreateQueries()
+}
+
+func setUser(s *state, name string) error {
+	s.cfg.Override(config.Profile{CurrentUserName: name})
+	return nil
+}
+
+func completeProfiles(s *state) ([]string, error) {
+	return s.cfg.ProfileNames(), nil
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/babanini95/gatorcli/internal/config"
+)
+
+func TestProfileCommands(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+
+	output := mustRun(t, s, "", "profile", "add", "work", "sqlite:work.db")
+	if !strings.Contains(output, "Profile work has been added") {
+		t.Errorf("profile add printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "profile", "add", "work", "memory://"); err == nil {
+		t.Error("adding work twice succeeded")
+	}
+	if _, err := runCommand(t, s, "", "profile", "add", "staging", "mysql://localhost/gator"); err == nil {
+		t.Error("adding a profile with an unsupported db_url succeeded")
+	}
+
+	want := "* default (current)\n  db:   memory://\n  user: alice\n* work\n  db:   sqlite:work.db\n"
+	if output := mustRun(t, s, "", "profile", "list"); output != want {
+		t.Errorf("profile list printed %q, want %q", output, want)
+	}
+
+	// --profile switches for this run only. GATOR_DB_URL still picks the
+	// memory store, so work starts with an empty database.
+	rest, err := parseGlobalFlags(s, []string{"--profile", "work", "following"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || s.cfg.ActiveProfile() != "work" || s.cfg.CurrentUserName != "" {
+		t.Errorf("--profile work left %q with profile %s and user %q", rest, s.cfg.ActiveProfile(), s.cfg.CurrentUserName)
+	}
+	if isUserExist(s, "alice") {
+		t.Error("the work profile uses the database of the default one")
+	}
+	mustRun(t, s, "", "register", "bob")
+	if cfg, err := config.Read(); err != nil || cfg.ActiveProfile() != config.DefaultProfile || cfg.CurrentUserName != "alice" {
+		t.Errorf("--profile was saved: %v", err)
+	}
+	if _, err := parseGlobalFlags(s, []string{"--profile", "staging", "following"}); err == nil {
+		t.Error("--profile with an unknown profile succeeded")
+	}
+
+	if output := mustRun(t, s, "", "profile", "use", "work"); output != "Now using profile work\n" {
+		t.Errorf("profile use printed %q", output)
+	}
+	cfg, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveProfile() != "work" || cfg.CurrentUserName != "bob" {
+		t.Errorf("after profile use the config has profile %s and user %q", cfg.ActiveProfile(), cfg.CurrentUserName)
+	}
+	if _, err := runCommand(t, s, "", "profile", "use", "staging"); err == nil {
+		t.Error("using an unknown profile succeeded")
+	}
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/config"
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// pruneInterval is how often agg prunes posts.
+const pruneInterval = time.Hour
+
+// retentionPolicy is the retention in effect for a feed. Zero maxAge and
+// maxPosts mean no limit.
+type retentionPolicy struct {
+	maxAge      time.Duration
+	maxPosts    int64
+	keepUnread  bool
+	keepStarred bool
+}
+
+func globalRetention(r config.Retention) (retentionPolicy, error) {
+	policy := retentionPolicy{
+		maxPosts:    int64(r.MaxPosts),
+		keepUnread:  r.KeepUnread,
+		keepStarred: r.KeepsStarred(),
+	}
+	if r.MaxAge != "" {
+		age, err := config.ParseAge(r.MaxAge)
+		if err != nil {
+			return policy, err
+		}
+		policy.maxAge = age
+	}
+	return policy, nil
+}
+
+// feedRetention applies the overrides of a feed to the global policy.
+func feedRetention(global retentionPolicy, maxAge sql.NullInt64, maxPosts sql.NullInt32, keepUnread, keepStarred sql.NullBool) retentionPolicy {
+	policy := global
+	if maxAge.Valid {
+		policy.maxAge = time.Duration(maxAge.Int64) * time.Second
+	}
+	if maxPosts.Valid {
+		policy.maxPosts = int64(maxPosts.Int32)
+	}
+	if keepUnread.Valid {
+		policy.keepUnread = keepUnread.Bool
+	}
+	if keepStarred.Valid {
+		policy.keepStarred = keepStarred.Bool
+	}
+	return policy
+}
+
+func (p retentionPolicy) prunes() bool {
+	return p.maxAge > 0 || p.maxPosts > 0
+}
+
+func (p retentionPolicy) params(feedID uuid.UUID) database.CountPrunablePostsParams {
+	params := database.CountPrunablePostsParams{
+		FeedID:      uuid.NullUUID{UUID: feedID, Valid: true},
+		KeepUnread:  p.keepUnread,
+		KeepStarred: p.keepStarred,
+	}
+	if p.maxAge > 0 {
+		params.Before = sqlTime(time.Now().Add(-p.maxAge))
+	}
+	if p.maxPosts > 0 {
+		params.MaxPosts = sql.NullInt64{Int64: p.maxPosts, Valid: true}
+	}
+	return params
+}
+
+func (p retentionPolicy) String() string {
+	maxAge, maxPosts := "no limit", "no limit"
+	if p.maxAge > 0 {
+		maxAge = formatAge(p.maxAge)
+	}
+	if p.maxPosts > 0 {
+		maxPosts = fmt.Sprint(p.maxPosts)
+	}
+	return fmt.Sprintf("max age %s, max posts %s, keep unread %t, keep starred %t",
+		maxAge, maxPosts, p.keepUnread, p.keepStarred)
+}
+
+func formatAge(age time.Duration) string {
+	if age%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", age/(24*time.Hour))
+	}
+	return age.String()
+}
+
+type pruneRecord struct {
+	FeedName sql.NullString
+	FeedUrl  sql.NullString
+	Removed  int64
+}
+
+// prunePosts applies the retention policies to every feed and returns the
+// number of posts removed per feed. With dryRun nothing is deleted.
+func prunePosts(ctx context.Context, s *state, dryRun bool) ([]pruneRecord, error) {
+	global, err := globalRetention(s.cfg.Retention)
+	if err != nil {
+		return nil, err
+	}
+	feeds, err := s.db.ListFeedsWithRetention(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds: %v", err)
+	}
+
+	records := []pruneRecord{}
+	for _, feed := range feeds {
+		policy := feedRetention(global, feed.MaxAge, feed.MaxPosts, feed.KeepUnread, feed.KeepStarred)
+		if !policy.prunes() {
+			continue
+		}
+
+		var removed int64
+		params := policy.params(feed.ID)
+		if dryRun {
+			removed, err = s.db.CountPrunablePosts(ctx, params)
+		} else {
+			removed, err = s.db.PrunePosts(ctx, database.PrunePostsParams(params))
+		}
+		if err != nil {
+			return records, fmt.Errorf("failed to prune %s: %v", feed.Url.String, err)
+		}
+		if removed > 0 {
+			records = append(records, pruneRecord{FeedName: feed.Name, FeedUrl: feed.Url, Removed: removed})
+		}
+	}
+	return records, nil
+}
+
+func printPruned(records []pruneRecord, verb string) {
+	var total int64
+	for _, record := range records {
+		fmt.Printf("%s: %d posts %s\n", record.FeedName.String, record.Removed, verb)
+		total += record.Removed
+	}
+	fmt.Printf("%d posts %s\n", total, verb)
+}
+
+func handlerPrune(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dryRun := flags.Bool("dry-run", false, "only count the posts that would be removed")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("prune: %v", err)
+	}
+
+	records, err := prunePosts(s.ctx, s, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "removed"
+	if *dryRun {
+		verb = "would be removed"
+	}
+	return s.render(records, func() {
+		printPruned(records, verb)
+	})
+}
+
+// handlerFeedRetention shows the retention policy of a feed, or changes the
+// settings given as flags. Unset settings follow the config.
+func handlerFeedRetention(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("feed retention", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	maxAge := flags.String("max-age", "", "remove posts older than this, e.g. 30d (0 for no limit)")
+	maxPosts := flags.Int("max-posts", 0, "keep only this many posts (0 for no limit)")
+	keepUnread := flags.Bool("keep-unread", false, "keep posts a follower hasn't read")
+	keepStarred := flags.Bool("keep-starred", true, "keep starred posts")
+	clear := flags.Bool("clear", false, "follow the config for every setting")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("feed retention: %v", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("feed retention command expected a feed url")
+	}
+
+	ctx := s.ctx
+	feed, err := getFeed(s, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	retention, err := s.db.GetFeedRetention(ctx, feed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get retention: %v", err)
+	}
+	retention.FeedID = feed.ID
+
+	if flags.NFlag() > 0 {
+		if err := checkFeedOwner(user, feed.UserID); err != nil {
+			return err
+		}
+	}
+	if *clear {
+		if err := s.db.DeleteFeedRetention(ctx, feed.ID); err != nil {
+			return fmt.Errorf("failed to clear retention: %v", err)
+		}
+		retention = database.FeedRetention{FeedID: feed.ID}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-age":
+			age, err := config.ParseAge(*maxAge)
+			if err != nil {
+				flagErr = err
+			}
+			retention.MaxAge = sql.NullInt64{Int64: int64(age / time.Second), Valid: true}
+		case "max-posts":
+			if *maxPosts < 0 {
+				flagErr = fmt.Errorf("max-posts can not be negative")
+			}
+			retention.MaxPosts = sql.NullInt32{Int32: int32(*maxPosts), Valid: true}
+		case "keep-unread":
+			retention.KeepUnread = sql.NullBool{Bool: *keepUnread, Valid: true}
+		case "keep-starred":
+			retention.KeepStarred = sql.NullBool{Bool: *keepStarred, Valid: true}
+		}
+	})
+	if flagErr != nil {
+		return flagErr
+	}
+
+	if flags.NFlag() > 0 && !(*clear && flags.NFlag() == 1) {
+		err := s.db.SetFeedRetention(ctx, database.SetFeedRetentionParams(retention))
+		if err != nil {
+			return fmt.Errorf("failed to set retention: %v", err)
+		}
+	}
+
+	global, err := globalRetention(s.cfg.Retention)
+	if err != nil {
+		return err
+	}
+	policy := feedRetention(global, retention.MaxAge, retention.MaxPosts, retention.KeepUnread, retention.KeepStarred)
+	fmt.Printf("Retention of %s: %s\n", feed.Name.String, policy)
+	return nil
+}
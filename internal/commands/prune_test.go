@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestFeedRetention(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	blogItems := []rssItem{}
+	for i := 1; i <= 4; i++ {
+		blogItems = append(blogItems, rssItem{fmt.Sprintf("Post %d", i), now.Add(-time.Duration(i) * time.Hour)})
+	}
+	blogURL := newFeedServer(t, blogItems...).URL + "/feed.xml"
+	newsURL := newFeedServer(t,
+		rssItem{"New news", now.Add(-time.Hour)},
+		rssItem{"Old news", now.Add(-60 * 24 * time.Hour)},
+	).URL + "/feed.xml"
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", blogURL)
+	mustRun(t, s, "", "addfeed", "News", newsURL)
+	mustRun(t, s, "", "agg", "--once")
+
+	alice := mustGetUser(t, s, "alice")
+	posts, err := s.db.GetPostsForUser(s.ctx, database.GetPostsForUserParams{
+		UserID: uuid.NullUUID{UUID: alice.ID, Valid: true},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	postIDs := map[string]uuid.UUID{}
+	for _, post := range posts {
+		postIDs[post.Title.String] = post.ID
+	}
+
+	// Anyone can see the retention of a feed, only its owner can change it.
+	mustRun(t, s, "", "register", "bob")
+	output := mustRun(t, s, "", "feed", "retention", blogURL)
+	if output != "Retention of Blog: max age no limit, max posts no limit, keep unread false, keep starred true\n" {
+		t.Errorf("feed retention printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "feed", "retention", "--max-posts", "1", blogURL); err == nil {
+		t.Error("bob changed the retention of alice's feed")
+	}
+	mustRun(t, s, "", "login", "alice")
+	if _, err := runCommand(t, s, "", "feed", "retention", "--max-posts", "-1", blogURL); err == nil {
+		t.Error("a negative max-posts was accepted")
+	}
+
+	output = mustRun(t, s, "", "feed", "retention", "--max-posts", "2", blogURL)
+	if output != "Retention of Blog: max age no limit, max posts 2, keep unread false, keep starred true\n" {
+		t.Errorf("feed retention --max-posts printed %q", output)
+	}
+	err = s.db.SetPostStarred(s.ctx, database.SetPostStarredParams{
+		UserID:    alice.ID,
+		PostID:    postIDs["Post 4"],
+		StarredAt: sqlCurrentTime(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun := func(want ...string) {
+		t.Helper()
+		output := mustRun(t, s, "", "prune", "--dry-run")
+		for _, line := range want {
+			if !strings.Contains(output, line+"\n") {
+				t.Errorf("prune --dry-run printed %q, want %q", output, line)
+			}
+		}
+	}
+	// The starred Post 4 is kept, so only Post 3 goes.
+	dryRun("Blog: 1 posts would be removed", "1 posts would be removed")
+
+	mustRun(t, s, "", "config", "set", "retention.max_age", "30d")
+	dryRun("Blog: 1 posts would be removed", "News: 1 posts would be removed", "2 posts would be removed")
+	mustRun(t, s, "", "feed", "retention", "--max-age", "0", newsURL)
+	dryRun("Blog: 1 posts would be removed", "1 posts would be removed")
+
+	// alice hasn't read Post 3 yet.
+	mustRun(t, s, "", "feed", "retention", "--keep-unread", blogURL)
+	dryRun("0 posts would be removed")
+	err = s.db.SetPostRead(s.ctx, database.SetPostReadParams{
+		UserID: alice.ID,
+		PostID: postIDs["Post 3"],
+		ReadAt: sqlCurrentTime(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Posts 1 and 2 are within max posts.
+	dryRun("Blog: 1 posts would be removed", "1 posts would be removed")
+
+	if output := mustRun(t, s, "", "prune"); output != "Blog: 1 posts removed\n1 posts removed\n" {
+		t.Errorf("prune printed %q", output)
+	}
+	output = mustRun(t, s, "", "browse", "10")
+	for _, title := range []string{"Post 1", "Post 2", "Post 4", "New news", "Old news"} {
+		if !strings.Contains(output, title) {
+			t.Errorf("%s was pruned", title)
+		}
+	}
+	if strings.Contains(output, "Post 3") {
+		t.Error("Post 3 was kept")
+	}
+
+	output = mustRun(t, s, "", "feed", "retention", "--clear", blogURL)
+	if output != "Retention of Blog: max age 30d, max posts no limit, keep unread false, keep starred true\n" {
+		t.Errorf("feed retention --clear printed %q", output)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	global := retentionPolicy{maxAge: 30 * 24 * time.Hour, keepStarred: true}
+	got := feedRetention(global,
+		sql.NullInt64{Int64: 0, Valid: true},
+		sql.NullInt32{Int32: 5, Valid: true},
+		sql.NullBool{Bool: true, Valid: true},
+		sql.NullBool{Bool: false, Valid: true},
+	)
+	if want := (retentionPolicy{maxPosts: 5, keepUnread: true}); got != want {
+		t.Errorf("the feed overrides gave %+v, want %+v", got, want)
+	}
+	if !got.prunes() {
+		t.Error("a max posts policy doesn't prune")
+	}
+	if got := feedRetention(global, sql.NullInt64{}, sql.NullInt32{}, sql.NullBool{}, sql.NullBool{}); got != global {
+		t.Errorf("a feed without overrides has %+v, want %+v", got, global)
+	}
+	if (retentionPolicy{keepUnread: true}).prunes() {
+		t.Error("a policy without limits prunes")
+	}
+
+	if got := formatAge(48 * time.Hour); got != "2d" {
+		t.Errorf("formatAge(48h) = %s", got)
+	}
+	if got := formatAge(90 * time.Minute); got != "1h30m0s" {
+		t.Errorf("formatAge(90m) = %s", got)
+	}
+}
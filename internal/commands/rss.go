@@ -4,13 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/babanini95/gatorcli/internal/database"
@@ -64,24 +66,190 @@ func fetchFeed(ctx context.Context, feedURL string) (rss *RSSFeed, err error) {
 	return rss, nil
 }
 
-func handlerAgg(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 1 {
-		return fmt.Errorf("invalid argument")
+// aggFetchTimeout bounds fetching and saving a single feed, and so how long
+// agg takes to stop.
+const aggFetchTimeout = time.Minute
+
+// aggOptions are the flags and interval of agg.
+type aggOptions struct {
+	once     bool
+	pidFile  string
+	interval time.Duration
+}
+
+// aggFeeds are the feeds agg fetches: next returns the one to fetch next
+// and all of them, least recently fetched first, for --once.
+type aggFeeds struct {
+	next func(s *state) (database.Feed, error)
+	all  func(s *state) ([]database.Feed, error)
+}
+
+// followedFeeds are the feeds of every user, for --all.
+var followedFeeds = aggFeeds{
+	next: func(s *state) (database.Feed, error) {
+		return s.db.GetNextFollowedFeedToFetch(s.ctx)
+	},
+	all: func(s *state) ([]database.Feed, error) {
+		return s.db.ListFollowedFeedsToFetch(s.ctx)
+	},
+}
+
+// userFeeds are the feeds the user follows.
+func userFeeds(user database.User) aggFeeds {
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
+	return aggFeeds{
+		next: func(s *state) (database.Feed, error) {
+			return s.db.GetNextFeedToFetch(s.ctx, userID)
+		},
+		all: func(s *state) ([]database.Feed, error) {
+			return s.db.ListFeedsToFetch(s.ctx, userID)
+		},
 	}
+}
 
-	timeBetweenReqs, err := time.ParseDuration(cmd.arguments[0])
+// handlerAgg fetches the feeds the current user follows or, with --all,
+// the feeds every user follows without logging in, e.g. as a team-wide
+// service.
+func handlerAgg(s *state, cmd command) error {
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	once := flags.Bool("once", false, "fetch every followed feed once and exit")
+	pidFile := flags.String("pid-file", "", "lock file that keeps a second agg from starting")
+	all := flags.Bool("all", false, "fetch every feed with a follower, without logging in")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("agg: %v", err)
+	}
+
+	opts := aggOptions{once: *once, pidFile: *pidFile}
+	switch {
+	case *once && flags.NArg() == 0:
+	case !*once && flags.NArg() == 1:
+		var err error
+		opts.interval, err = time.ParseDuration(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if opts.interval <= 0 {
+			return fmt.Errorf("agg interval must be positive")
+		}
+	default:
+		return fmt.Errorf("agg command expected an interval, e.g. 1m, or --once")
+	}
+
+	if *all {
+		// The team-wide agg applies the retention policies, which do
+		// nothing until they are set in the config.
+		return runAgg(s, opts, followedFeeds, true)
+	}
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+		// Admins' agg also applies the retention policies now and then.
+		return runAgg(s, opts, userFeeds(user), user.Role == roleAdmin)
+	})(s, cmd)
+}
+
+func runAgg(s *state, opts aggOptions, feeds aggFeeds, prune bool) error {
+	if opts.pidFile == "" {
+		opts.pidFile = defaultAggPIDFile()
+	}
+	unlock, err := lockAgg(opts.pidFile)
 	if err != nil {
-		return err
+		return fmt.Errorf("can not lock %s: %v", opts.pidFile, err)
+	}
+	defer unlock()
+
+	// Ctrl-C and SIGTERM cancel s.ctx. agg then saves the feed it is
+	// fetching before it exits, see scrapeAndReport.
+	stopNotice := context.AfterFunc(s.ctx, func() {
+		fmt.Println("Stopping once the current feed is saved...")
+	})
+	defer stopNotice()
+
+	if opts.once {
+		return aggOnce(s, feeds, prune)
+	}
+
+	fmt.Printf("Collecting feeds every %s\n", opts.interval.String())
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		feed, err := feeds.next(s)
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		switch {
+		case err == nil:
+			scrapeAndReport(s, feed)
+			if s.ctx.Err() != nil {
+				return nil
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			// No feeds to fetch is normal, anything else is worth a
+			// look, e.g. a database that went away.
+			fmt.Printf("Failed to get the next feed: %v\n", err)
+		}
+
+		if prune && time.Since(lastPrune) >= pruneInterval {
+			lastPrune = time.Now()
+			aggPrune(s)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return nil
+		}
 	}
+}
 
-	fmt.Printf("Collecting feeds every %s\n", timeBetweenReqs.String())
-	ticker := time.NewTicker(timeBetweenReqs)
+// aggOnce fetches every feed once, least recently fetched first, for
+// running agg from cron. The feeds are listed up front, so a feed that
+// can't be marked fetched is only tried once.
+func aggOnce(s *state, feeds aggFeeds, prune bool) error {
+	due, err := feeds.all(s)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %v", err)
+	}
+	fetched := 0
+	for _, feed := range due {
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		if err := scrapeAndReport(s, feed); err == nil {
+			fetched++
+		}
+	}
+	if s.ctx.Err() != nil {
+		return nil
+	}
 
-	for ; ; <-ticker.C {
-		scrapeFeeds(s, user)
+	fmt.Printf("Fetched %d of %d feeds\n", fetched, len(due))
+	if prune {
+		aggPrune(s)
+	}
+	return nil
+}
+
+func aggPrune(s *state) {
+	records, err := prunePosts(s.ctx, s, false)
+	if err != nil {
+		fmt.Printf("Failed to prune posts: %v\n", err)
+	}
+	for _, record := range records {
+		fmt.Printf("Pruned %d posts from %s\n", record.Removed, record.FeedName.String)
 	}
 }
 
+// defaultAggPIDFile is the lock file of agg when --pid-file isn't given.
+func defaultAggPIDFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gator", "agg.pid")
+}
+
 func handlerAddFeed(s *state, cmd command, user database.User) error {
 	if len(cmd.arguments) < 2 {
 		fmt.Println("Need more arguments!")
@@ -106,7 +274,7 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 		},
 	}
 
-	feed, err := s.db.CreateFeed(context.Background(), params)
+	feed, err := s.db.CreateFeed(s.ctx, params)
 	if err != nil {
 		fmt.Printf("Failed to create feed: %v\n", err)
 		os.Exit(1)
@@ -125,7 +293,7 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 			Valid: true,
 		},
 	}
-	_, err = s.db.CreateFeedFollow(context.Background(), createFeedFollowParams)
+	_, err = s.db.CreateFeedFollow(s.ctx, createFeedFollowParams)
 	if err != nil {
 		fmt.Printf("Can not create feed follow: %v\n", err)
 		os.Exit(1)
@@ -136,27 +304,28 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 }
 
 func handlerFeeds(s *state, cmd command) error {
-	feeds, err := s.db.ListFeeds(context.Background())
+	feeds, err := s.db.ListFeeds(s.ctx)
 	if err != nil {
 		fmt.Printf("Failed to list all feeds: %v", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("FEED LIST")
-	for i, feed := range feeds {
-		fmt.Printf(
-			`%v. - Feed Name : %s
+	return s.render(feeds, func() {
+		fmt.Println("FEED LIST")
+		for i, feed := range feeds {
+			fmt.Printf(
+				`%v. - Feed Name : %s
    - URL	   : %s
    - User Name : %s
 `,
-			(i + 1), feed.Name.String, feed.Url.String, feed.UserName.String,
-		)
-	}
-	return nil
+				(i + 1), feed.Name.String, feed.Url.String, feed.UserName.String,
+			)
+		}
+	})
 }
 
 func handlerFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 1 {
+	if len(cmd.arguments) != 1 && len(cmd.arguments) != 2 {
 		fmt.Println("invalid argument")
 		os.Exit(1)
 	}
@@ -166,7 +335,7 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 		Valid:  true,
 	}
 
-	feed, err := s.db.GetFeedByUrl(context.Background(), urlNullString)
+	feed, err := s.db.GetFeedByUrl(s.ctx, urlNullString)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -184,8 +353,11 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 			Valid: true,
 		},
 	}
+	if len(cmd.arguments) == 2 {
+		params.Folder = sqlString(cmd.arguments[1])
+	}
 
-	row, err := s.db.CreateFeedFollow(context.Background(), params)
+	row, err := s.db.CreateFeedFollow(s.ctx, params)
 	if err != nil {
 		fmt.Printf("Can not create feed follow: %v\n", err)
 		os.Exit(1)
@@ -197,7 +369,7 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 
 func handlerFollowing(s *state, cmd command, user database.User) error {
 	feedsFollow, err := s.db.GetFeedFollowsForUser(
-		context.Background(),
+		s.ctx,
 		uuid.NullUUID{
 			UUID:  user.ID,
 			Valid: true,
@@ -208,11 +380,11 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 		os.Exit(1)
 	}
 
-	for _, feed := range feedsFollow {
-		fmt.Printf("%s\n", feed.FeedName.String)
-	}
-
-	return nil
+	return s.render(feedsFollow, func() {
+		for _, feed := range feedsFollow {
+			fmt.Printf("%s\n", feed.FeedName.String)
+		}
+	})
 }
 
 func handlerUnfollow(s *state, cmd command, user database.User) error {
@@ -231,7 +403,7 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 			Valid:  true,
 		},
 	}
-	err := s.db.DeleteFeedFollowsByUrl(context.Background(), params)
+	err := s.db.DeleteFeedFollowsByUrl(s.ctx, params)
 	if err != nil {
 		fmt.Printf("Failed to delete feeds follow:\n%v\n", err)
 		os.Exit(1)
@@ -240,6 +412,39 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 && len(cmd.arguments) != 2 {
+		return fmt.Errorf("folder command expected a feed url and an optional folder name")
+	}
+
+	params := database.SetFeedFollowFolderParams{
+		UpdatedAt: sqlCurrentTime(),
+		UserID: uuid.NullUUID{
+			UUID:  user.ID,
+			Valid: true,
+		},
+		Url: sqlString(cmd.arguments[0]),
+	}
+	if len(cmd.arguments) == 2 {
+		params.Folder = sqlString(cmd.arguments[1])
+	}
+
+	updated, err := s.db.SetFeedFollowFolder(s.ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to set folder: %v", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("you are not following %s", cmd.arguments[0])
+	}
+
+	if params.Folder.Valid {
+		fmt.Printf("Moved %s to %s\n", cmd.arguments[0], params.Folder.String)
+	} else {
+		fmt.Printf("Removed %s from its folder\n", cmd.arguments[0])
+	}
+	return nil
+}
+
 func handlerBrowse(s *state, cmd command, user database.User) error {
 	postLimit := 2
 	var err error = nil
@@ -254,72 +459,147 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
 		Limit:  int32(postLimit),
 	}
-	posts, err := s.db.GetPostsForUser(context.Background(), params)
+	posts, err := s.db.GetPostsForUser(s.ctx, params)
 	if err != nil {
 		return nil
 	}
 
-	for _, post := range posts {
-		fmt.Printf("\nTitle:  %s\nDescription:  %s\nURL:  %s\nPublished at: %s\n",
-			post.Title.String,
-			post.Description.String,
-			post.Url.String,
-			post.PublishedAt.Time.String(),
-		)
-	}
-	return nil
+	return s.render(posts, func() {
+		for _, post := range posts {
+			fmt.Printf("\nTitle:  %s\nDescription:  %s\nURL:  %s\nPublished at: %s\n",
+				post.Title.String,
+				post.Description.String,
+				post.Url.String,
+				post.PublishedAt.Time.String(),
+			)
+		}
+	})
 }
 
-func scrapeFeeds(s *state, user database.User) error {
-	feed, err := s.db.GetNextFeedToFetch(context.Background(), uuid.NullUUID{
-		UUID:  user.ID,
-		Valid: true,
-	})
+func handlerSearch(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) == 0 {
+		return fmt.Errorf("search command expected a query")
+	}
+
+	postLimit := 10
+	var err error = nil
+	if len(cmd.arguments) > 1 {
+		postLimit, err = strconv.Atoi(cmd.arguments[1])
+		if err != nil {
+			return fmt.Errorf("invalid limit: %v", err)
+		}
+	}
+
+	params := database.SearchPostsForUserParams{
+		UserID:      uuid.NullUUID{UUID: user.ID, Valid: true},
+		Pattern:     containsPattern(cmd.arguments[0]),
+		ResultLimit: int32(postLimit),
+	}
+	posts, err := s.db.SearchPostsForUser(s.ctx, params)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to search posts: %v", err)
 	}
 
-	err = s.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{
-		LastFetchedAt: sqlCurrentTime(),
-		ID:            feed.ID,
+	return s.render(posts, func() {
+		for _, post := range posts {
+			fmt.Printf("\nTitle:  %s\nURL:  %s\nPublished at: %s\n",
+				post.Title.String,
+				post.Url.String,
+				post.PublishedAt.Time.String(),
+			)
+		}
 	})
+}
+
+// scrapeAndReport fetches a feed and prints the outcome. The fetch isn't
+// cancelled with s.ctx, so a stopping agg doesn't leave a feed half done.
+func scrapeAndReport(s *state, feed database.Feed) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), aggFetchTimeout)
+	defer cancel()
+
+	result, err := scrapeFeed(ctx, s, feed)
+	for _, failure := range result.failures {
+		fmt.Printf("Skipped %s\n", failure)
+	}
 	if err != nil {
+		fmt.Printf("Failed to fetch %s: %v\n", feed.Name.String, err)
 		return err
 	}
+	fmt.Printf("Fetched %s: %d new posts, %d already saved, %d failed\n",
+		feed.Name.String, result.inserted, result.skipped, len(result.failures))
+	return nil
+}
 
-	rss, err := fetchFeed(context.Background(), feed.Url.String)
-	if err != nil {
-		return err
+// scrapeResult tells what became of the items of a fetched feed.
+type scrapeResult struct {
+	inserted int64
+	skipped  int64
+	// failures describe the items that couldn't be saved.
+	failures []string
+}
+
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed) (scrapeResult, error) {
+	result := scrapeResult{}
+	rss, fetchErr := fetchFeed(ctx, feed.Url.String)
+
+	posts := []database.CreatePostParams{}
+	if fetchErr == nil {
+		for _, item := range rss.Channel.Item {
+			title := html.UnescapeString(item.Title)
+			pubAt, err := convertRssTimestamp(item.PubDate)
+			if err != nil {
+				result.failures = append(result.failures, fmt.Sprintf("%q: %v", title, err))
+				continue
+			}
+			posts = append(posts, database.CreatePostParams{
+				ID:          uuid.New(),
+				CreatedAt:   sqlCurrentTime(),
+				UpdatedAt:   sqlCurrentTime(),
+				Title:       sqlString(title),
+				Url:         sqlString(item.Link),
+				Description: sqlString(html.UnescapeString(item.Description)),
+				PublishedAt: sqlTime(pubAt),
+				FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+			})
+		}
 	}
 
-	for _, item := range rss.Channel.Item {
-		postParams := database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   sqlCurrentTime(),
-			UpdatedAt:   sqlCurrentTime(),
-			Title:       sqlString(html.UnescapeString(item.Title)),
-			Url:         sqlString(item.Link),
-			Description: sqlString(html.UnescapeString(item.Description)),
-			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+	// The feed is marked fetched together with saving its posts, so a
+	// crash can't leave it marked with only some of them saved.
+	err := s.db.InTx(ctx, func(tx database.Store) error {
+		err := tx.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+			LastFetchedAt: sqlCurrentTime(),
+			ID:            feed.ID,
+		})
+		if err != nil {
+			return err
 		}
 
-		pubAt, err := convertRssTimestamp(item.PubDate)
+		// Remember the outcome of the fetch for "gator feed show".
+		lastFetchError := sql.NullString{}
+		if fetchErr != nil {
+			lastFetchError = sqlString(fetchErr.Error())
+		}
+		err = tx.SetFeedFetchError(ctx, database.SetFeedFetchErrorParams{
+			LastFetchError: lastFetchError,
+			ID:             feed.ID,
+		})
 		if err != nil {
 			return err
 		}
-		postParams.PublishedAt = sql.NullTime{Time: pubAt, Valid: true}
 
-		if err = s.db.CreatePost(context.Background(), postParams); err != nil {
-			errStr := err.Error()
-			if !strings.Contains(errStr, "duplicate key") {
-				fmt.Printf("err: %v\nitem: %s", err, item.Title)
-			}
+		inserted, err := tx.CreatePosts(ctx, posts)
+		if err != nil {
+			return fmt.Errorf("failed to save posts: %v", err)
 		}
-		fmt.Printf("Successfully save \"%s\" to database\n", html.UnescapeString(item.Title))
+		result.inserted = inserted
+		result.skipped = int64(len(posts)) - inserted
+		return nil
+	})
+	if err != nil {
+		return scrapeResult{failures: result.failures}, err
 	}
-	fmt.Println("----------------------------------------")
-
-	return nil
+	return result, fetchErr
 }
 
 func convertRssTimestamp(timeStamp string) (time.Time, error) {
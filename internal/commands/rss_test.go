@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+)
+
+// failingPostsStore fails to save posts, in and out of transactions.
+type failingPostsStore struct {
+	database.Store
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (f failingPostsStore) CreatePosts(ctx context.Context, posts []database.CreatePostParams) (int64, error) {
+	return 0, errDiskFull
+}
+
+func (f failingPostsStore) InTx(ctx context.Context, fn func(database.Store) error) error {
+	return f.Store.InTx(ctx, func(tx database.Store) error {
+		return fn(failingPostsStore{tx})
+	})
+}
+
+func TestScrapeFeed(t *testing.T) {
+	s := newTestState(t)
+	published := time.Now().Add(-time.Hour).Format(time.RFC1123Z)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>`)
+		fmt.Fprintf(w, `<item><title>Good post</title><link>https://example.com/good</link><pubDate>%s</pubDate></item>`, published)
+		fmt.Fprint(w, `<item><title>Bad date</title><link>https://example.com/bad</link><pubDate>yesterday</pubDate></item>`)
+		fmt.Fprintf(w, `<item><title>Fish &amp;amp; chips</title><link>https://example.com/fish</link><pubDate>%s</pubDate></item>`, published)
+		fmt.Fprint(w, `</channel></rss>`)
+	}))
+	t.Cleanup(server.Close)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL)
+	feed, err := getFeed(s, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed insert leaves the feed as it was, to be fetched again.
+	db := s.db
+	s.db = failingPostsStore{db}
+	if _, err := scrapeFeed(s.ctx, s, feed); !strings.Contains(fmt.Sprint(err), errDiskFull.Error()) {
+		t.Errorf("scrapeFeed returned %v, want %v", err, errDiskFull)
+	}
+	s.db = db
+	if feed, _ := getFeed(s, server.URL); feed.LastFetchedAt.Valid {
+		t.Error("the feed is marked fetched without its posts")
+	}
+
+	result, err := scrapeFeed(s.ctx, s, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.inserted != 2 || result.skipped != 0 || len(result.failures) != 1 || !strings.Contains(result.failures[0], `"Bad date"`) {
+		t.Errorf("scrapeFeed returned %+v", result)
+	}
+	if feed, _ := getFeed(s, server.URL); !feed.LastFetchedAt.Valid {
+		t.Error("the feed is not marked fetched")
+	}
+	if output := mustRun(t, s, "", "search", "fish"); !strings.Contains(output, "Fish & chips") {
+		t.Errorf("the title was not unescaped: %q", output)
+	}
+
+	output := mustRun(t, s, "", "agg", "--once")
+	for _, want := range []string{`Skipped "Bad date"`, "Fetched Test: 0 new posts, 2 already saved, 1 failed"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("agg printed %q, want %q", output, want)
+		}
+	}
+}
+
+func TestAggAll(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	blog := newFeedServer(t, rssItem{"Blog post", now.Add(-time.Hour)})
+	news := newFeedServer(t, rssItem{"News post", now.Add(-2 * time.Hour)})
+	unfollowed := newFeedServer(t, rssItem{"Unfollowed post", now})
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", blog.URL+"/feed.xml")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "addfeed", "News", news.URL+"/feed.xml")
+	mustRun(t, s, "", "addfeed", "Unfollowed", unfollowed.URL+"/feed.xml")
+	mustRun(t, s, "", "unfollow", unfollowed.URL+"/feed.xml")
+
+	// A member's agg only fetches their own feeds.
+	output := mustRun(t, s, "", "agg", "--once")
+	if !strings.Contains(output, "Fetched News") || strings.Contains(output, "Fetched Blog") || !strings.Contains(output, "Fetched 1 of 1 feeds") {
+		t.Errorf("bob's agg printed %q", output)
+	}
+
+	// --all fetches the feeds of every user, the least recently fetched
+	// first, and skips feeds nobody follows.
+	mustRun(t, s, "", "logout")
+	feed, err := followedFeeds.next(s)
+	if err != nil || feed.Name.String != "Blog" {
+		t.Errorf("the next feed for --all is %s: %v", feed.Name.String, err)
+	}
+	output = mustRun(t, s, "", "agg", "--once", "--all")
+	blogAt, newsAt := strings.Index(output, "Fetched Blog"), strings.Index(output, "Fetched News")
+	if blogAt < 0 || newsAt < 0 || blogAt > newsAt || !strings.Contains(output, "Fetched 2 of 2 feeds") {
+		t.Errorf("agg --all printed %q", output)
+	}
+	if strings.Contains(output, "Unfollowed") {
+		t.Errorf("agg --all fetched a feed without followers: %q", output)
+	}
+
+	mustRun(t, s, "", "login", "alice")
+	if output := mustRun(t, s, "", "browse", "10"); !strings.Contains(output, "Blog post") {
+		t.Errorf("after agg --all alice's browse printed %q", output)
+	}
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const defaultServeAddr = "localhost:8080"
+
+func handlerServe(s *state, cmd command) error {
+	addr := defaultServeAddr
+	if len(cmd.arguments) > 0 {
+		addr = cmd.arguments[0]
+	}
+
+	api := &apiServer{s: s}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	fmt.Printf("Serving the gator API on http://%s\n", addr)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-s.ctx.Done():
+	}
+
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var ansiColors = map[string]string{
+	"bold":    "\033[1m",
+	"red":     "\033[31m",
+	"green":   "\033[32m",
+	"yellow":  "\033[33m",
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"gray":    "\033[90m",
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+var templateFuncs = template.FuncMap{
+	"truncate":  truncate,
+	"stripHTML": stripHTML,
+	"relTime":   relativeTime,
+	"color":     colorize,
+}
+
+// setTemplate accepts either the name of a template from the config file or
+// the template text itself.
+func setTemplate(s *state, value string) error {
+	text, ok := s.cfg.Templates[value]
+	if !ok {
+		text = value
+	}
+
+	_, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+
+	s.template = text
+	return nil
+}
+
+// renderTemplate executes the template once for every row. The fields are
+// available under the same names as in the --output formats, e.g.
+// {{.title}} or {{.published_at | relTime}}.
+func (s *state) renderTemplate(rows any) error {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(s.template)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+
+	v := reflect.ValueOf(rows)
+	for i := 0; i < v.Len(); i++ {
+		data := map[string]any{}
+		row := v.Index(i)
+		for j := 0; j < row.NumField(); j++ {
+			if !row.Type().Field(j).IsExported() {
+				continue
+			}
+
+			value := plainValue(row.Field(j).Interface())
+			if value == nil {
+				value = ""
+			}
+			data[snakeCase(row.Type().Field(j).Name)] = value
+		}
+
+		out := strings.Builder{}
+		if err := tmpl.Execute(&out, data); err != nil {
+			return fmt.Errorf("can not execute template: %v", err)
+		}
+		if !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+		fmt.Print(out.String())
+	}
+	return nil
+}
+
+// truncate shortens s to at most n characters, ending with "..." when it
+// was cut.
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+func stripHTML(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
+
+// relativeTime formats t as e.g. "5 minutes ago". Empty values stay empty.
+func relativeTime(t any) string {
+	ts, ok := t.(time.Time)
+	if !ok || ts.IsZero() {
+		return ""
+	}
+
+	d := time.Since(ts)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	var amount int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount, unit = int(d.Minutes()), "minute"
+	case d < 24*time.Hour:
+		amount, unit = int(d.Hours()), "hour"
+	case d < 30*24*time.Hour:
+		amount, unit = int(d.Hours()/24), "day"
+	case d < 365*24*time.Hour:
+		amount, unit = int(d.Hours()/24/30), "month"
+	default:
+		amount, unit = int(d.Hours()/24/365), "year"
+	}
+
+	if amount != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s %s", amount, unit, suffix)
+}
+
+// colorize wraps s in an ANSI color unless NO_COLOR is set.
+func colorize(color string, s string) string {
+	code, ok := ansiColors[color]
+	if !ok || s == "" || os.Getenv("NO_COLOR") != "" {
+		return s
+	}
+	return code + s + "\033[0m"
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateOutput(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	server := newFeedServer(t,
+		rssItem{"Go 1.24 is out", now.Add(-3 * time.Hour)},
+		rssItem{"Rust news", now.Add(-2 * 24 * time.Hour)},
+	)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+	mustRun(t, s, "", "agg", "--once")
+
+	if err := setTemplate(s, "{{.title}} ({{.published_at | relTime}})"); err != nil {
+		t.Fatal(err)
+	}
+	want := "Go 1.24 is out (3 hours ago)\nRust news (2 days ago)\n"
+	if output := mustRun(t, s, "", "browse", "10"); output != want {
+		t.Errorf("browse printed %q, want %q", output, want)
+	}
+
+	// A template of the config, by name.
+	mustRun(t, s, "", "config", "set", "templates.short", `{{.title | truncate 8}}: {{.url}}{{"\n"}}`)
+	if err := setTemplate(s, "short"); err != nil {
+		t.Fatal(err)
+	}
+	want = "Go 1....: https://example.com/Go-1.24-is-out\n"
+	if output := mustRun(t, s, "", "search", "go"); output != want {
+		t.Errorf("search printed %q, want %q", output, want)
+	}
+
+	if err := setTemplate(s, "{{.title"); err == nil {
+		t.Error("an invalid template was accepted")
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	for _, test := range []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{10, "short", "short"},
+		{8, "a longer title", "a lon..."},
+		{3, "abcdef", "abc"},
+		{4, "héllo wörld", "h..."},
+	} {
+		if got := truncate(test.n, test.s); got != test.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", test.n, test.s, got, test.want)
+		}
+	}
+
+	if got := stripHTML("<p>Fish &amp; <b>chips</b></p>\n<p>today</p>"); got != "Fish & chips today" {
+		t.Errorf("stripHTML = %q", got)
+	}
+
+	now := time.Now()
+	for value, want := range map[any]string{
+		now.Add(-10 * time.Second):          "just now",
+		now.Add(-time.Minute - time.Second): "1 minute ago",
+		now.Add(-5 * time.Hour):             "5 hours ago",
+		now.Add(-40 * 24 * time.Hour):       "1 month ago",
+		now.Add(-800 * 24 * time.Hour):      "2 years ago",
+		now.Add(2*time.Hour + time.Minute):  "2 hours from now",
+		"":                                  "",
+		time.Time{}:                         "",
+	} {
+		if got := relativeTime(value); got != want {
+			t.Errorf("relTime(%v) = %q, want %q", value, got, want)
+		}
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if got := colorize("red", "alert"); got != "\033[31malert\033[0m" {
+		t.Errorf("color red = %q", got)
+	}
+	if got := colorize("plaid", "alert"); got != "alert" {
+		t.Errorf("an unknown color changed the text to %q", got)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if got := colorize("red", "alert"); got != "alert" {
+		t.Errorf("NO_COLOR still colored the text: %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/config"
+)
+
+// longRunningCommands run until they are stopped, so only --timeout
+// applies to them. GATOR_TIMEOUT and the config file are usually set for
+// every command, and would stop agg or serve after a few seconds.
+var longRunningCommands = map[string]bool{
+	"agg":   true,
+	"serve": true,
+	"tui":   true,
+	"watch": true,
+}
+
+// setTimeout is the --timeout global flag.
+func setTimeout(s *state, value string) error {
+	return s.cfg.OverrideTimeout(value)
+}
+
+// commandTimeout returns how long a command may run, 0 for no limit:
+// --timeout or GATOR_TIMEOUT, else timeouts.<command>, else
+// timeouts.default. Long running commands only stop after --timeout.
+func commandTimeout(s *state, name string) time.Duration {
+	if longRunningCommands[name] {
+		timeout, _ := s.cfg.FlagTimeout()
+		return timeout
+	}
+	if timeout, ok := s.cfg.Timeout(name); ok {
+		return timeout
+	}
+	timeout, _ := s.cfg.Timeout(config.DefaultTimeout)
+	return timeout
+}
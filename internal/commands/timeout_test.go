@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/config"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "config", "set", "timeouts.default", "30s")
+	mustRun(t, s, "", "config", "set", "timeouts.backup", "10m")
+	mustRun(t, s, "", "config", "set", "timeouts.serve", "1m")
+
+	check := func(name string, want time.Duration) {
+		t.Helper()
+		if got := commandTimeout(s, name); got != want {
+			t.Errorf("%s times out after %s, want %s", name, got, want)
+		}
+	}
+	check("browse", 30*time.Second)
+	check("backup", 10*time.Minute)
+	for name := range longRunningCommands {
+		check(name, 0)
+	}
+
+	t.Setenv("GATOR_TIMEOUT", "5s")
+	cfg, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.cfg = cfg
+	check("browse", 5*time.Second)
+	check("backup", 5*time.Second)
+	check("serve", 0)
+	check("agg", 0)
+
+	if err := setTimeout(s, "1h"); err != nil {
+		t.Fatal(err)
+	}
+	check("browse", time.Hour)
+	check("serve", time.Hour)
+	check("agg", time.Hour)
+}
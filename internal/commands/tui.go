@@ -0,0 +1,628 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+const (
+	tuiPostLimit      = 200
+	tuiDefaultRefresh = 30 * time.Second
+)
+
+type tuiPane int
+
+const (
+	paneSidebar tuiPane = iota
+	panePosts
+	paneReader
+)
+
+type sidebarKind int
+
+const (
+	sidebarAll sidebarKind = iota
+	sidebarFolder
+	sidebarFeed
+)
+
+type sidebarItem struct {
+	kind   sidebarKind
+	label  string
+	folder string
+	feedID uuid.UUID
+	unread int64
+	indent bool
+}
+
+// key identifies the item across reloads.
+func (i sidebarItem) key() string {
+	return fmt.Sprintf("%d/%s/%s", i.kind, i.folder, i.feedID)
+}
+
+type tui struct {
+	s    *state
+	user database.User
+
+	width  int
+	height int
+	focus  tuiPane
+
+	sidebar    []sidebarItem
+	sidebarIdx int
+	sidebarTop int
+
+	posts   []database.GetPostsWithStateForUserRow
+	postIdx int
+	postTop int
+
+	reading   bool
+	readerTop int
+
+	status string
+}
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+	refresh := tuiDefaultRefresh
+	if len(cmd.arguments) > 0 {
+		var err error
+		refresh, err = time.ParseDuration(cmd.arguments[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("tui command needs an interactive terminal")
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("can not switch terminal to raw mode: %v", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Alternate screen and hidden cursor, restored on exit.
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	t := &tui{s: s, user: user}
+	t.resize()
+	t.reload()
+	t.draw()
+
+	keys := make(chan string)
+	go readKeys(keys)
+
+	refreshTicker := time.NewTicker(refresh)
+	defer refreshTicker.Stop()
+	resizeTicker := time.NewTicker(250 * time.Millisecond)
+	defer resizeTicker.Stop()
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || t.handleKey(key) {
+				return nil
+			}
+		case <-refreshTicker.C:
+			t.reload()
+		case <-s.ctx.Done():
+			// The terminal is raw, so Ctrl-C is a key press and this is
+			// a SIGTERM or a timeout.
+			return nil
+		case <-resizeTicker.C:
+			if !t.resize() {
+				continue
+			}
+		}
+		t.draw()
+	}
+}
+
+// readKeys sends every key press to keys. Escape sequences, such as the
+// arrow keys, are sent as a single string.
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if buf[0] == '\033' {
+			keys <- string(buf[:n])
+			continue
+		}
+		for _, r := range string(buf[:n]) {
+			keys <- string(r)
+		}
+	}
+}
+
+// resize reports whether the terminal size changed.
+func (t *tui) resize() bool {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	if width == t.width && height == t.height {
+		return false
+	}
+	t.width, t.height = width, height
+	return true
+}
+
+// handleKey reports whether the user asked to quit.
+func (t *tui) handleKey(key string) bool {
+	t.status = ""
+	switch key {
+	case "q", "\x03":
+		return true
+	case "\t", "l", "\033[C":
+		t.focusNext(1)
+	case "\033[Z", "h", "\033[D":
+		t.focusNext(-1)
+	case "j", "\033[B":
+		t.move(1)
+	case "k", "\033[A":
+		t.move(-1)
+	case " ", "\033[6~":
+		t.move(t.pageSize())
+	case "b", "\033[5~":
+		t.move(-t.pageSize())
+	case "g", "\033[H":
+		t.move(-1 << 30)
+	case "G", "\033[F":
+		t.move(1 << 30)
+	case "\r":
+		t.enter()
+	case "r":
+		t.toggleRead()
+	case "s":
+		t.toggleStar()
+	case "R":
+		t.reload()
+		t.status = "Refreshed"
+	case "\033":
+		if t.reading {
+			t.reading = false
+			t.focus = panePosts
+		}
+	}
+	return false
+}
+
+func (t *tui) focusNext(step int) {
+	last := panePosts
+	if t.reading {
+		last = paneReader
+	}
+	t.focus += tuiPane(step)
+	if t.focus < paneSidebar {
+		t.focus = last
+	}
+	if t.focus > last {
+		t.focus = paneSidebar
+	}
+}
+
+func (t *tui) pageSize() int {
+	if t.focus == paneReader {
+		return t.readerHeight() - 1
+	}
+	return t.listHeight() - 1
+}
+
+func (t *tui) move(step int) {
+	switch t.focus {
+	case paneSidebar:
+		t.sidebarIdx = clamp(t.sidebarIdx+step, 0, len(t.sidebar)-1)
+	case panePosts:
+		t.postIdx = clamp(t.postIdx+step, 0, len(t.posts)-1)
+	case paneReader:
+		t.readerTop = clamp(t.readerTop+step, 0, len(t.readerLines())-1)
+	}
+}
+
+func (t *tui) enter() {
+	switch t.focus {
+	case paneSidebar:
+		t.postIdx, t.postTop = 0, 0
+		t.reading = false
+		t.loadPosts()
+		t.focus = panePosts
+	case panePosts:
+		if len(t.posts) == 0 {
+			return
+		}
+		t.reading = true
+		t.readerTop = 0
+		t.focus = paneReader
+		if !t.posts[t.postIdx].ReadAt.Valid {
+			t.toggleRead()
+		}
+	}
+}
+
+func (t *tui) selectedPost() *database.GetPostsWithStateForUserRow {
+	if len(t.posts) == 0 {
+		return nil
+	}
+	return &t.posts[t.postIdx]
+}
+
+func (t *tui) toggleRead() {
+	post := t.selectedPost()
+	if post == nil {
+		return
+	}
+
+	readAt := sql.NullTime{}
+	if !post.ReadAt.Valid {
+		readAt = sqlCurrentTime()
+	}
+	err := t.s.db.SetPostRead(t.s.ctx, database.SetPostReadParams{
+		UserID: t.user.ID,
+		PostID: post.ID,
+		ReadAt: readAt,
+	})
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to update post: %v", err)
+		return
+	}
+	post.ReadAt = readAt
+	t.loadSidebar()
+}
+
+func (t *tui) toggleStar() {
+	post := t.selectedPost()
+	if post == nil {
+		return
+	}
+
+	starredAt := sql.NullTime{}
+	if !post.StarredAt.Valid {
+		starredAt = sqlCurrentTime()
+	}
+	err := t.s.db.SetPostStarred(t.s.ctx, database.SetPostStarredParams{
+		UserID:    t.user.ID,
+		PostID:    post.ID,
+		StarredAt: starredAt,
+	})
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to update post: %v", err)
+		return
+	}
+	post.StarredAt = starredAt
+}
+
+// reload fetches the sidebar and the selected post list again, keeping the
+// current selection where possible.
+func (t *tui) reload() {
+	t.loadSidebar()
+
+	var selected uuid.UUID
+	if post := t.selectedPost(); post != nil {
+		selected = post.ID
+	}
+	t.loadPosts()
+	for i, post := range t.posts {
+		if post.ID == selected {
+			t.postIdx = i
+		}
+	}
+}
+
+func (t *tui) loadSidebar() {
+	ctx := t.s.ctx
+	userID := uuid.NullUUID{UUID: t.user.ID, Valid: true}
+
+	var selected string
+	if len(t.sidebar) > 0 {
+		selected = t.sidebar[t.sidebarIdx].key()
+	}
+
+	follows, err := t.s.db.GetFeedFollowsForUser(ctx, userID)
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to load feeds: %v", err)
+		return
+	}
+	counts, err := t.s.db.GetUnreadCountsForUser(ctx, userID)
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to load unread counts: %v", err)
+		return
+	}
+
+	unread := map[uuid.UUID]int64{}
+	var total int64
+	for _, count := range counts {
+		unread[count.FeedID.UUID] = count.Unread
+		total += count.Unread
+	}
+
+	sort.Slice(follows, func(i, j int) bool {
+		if follows[i].Folder.String != follows[j].Folder.String {
+			return follows[i].Folder.String < follows[j].Folder.String
+		}
+		return follows[i].FeedName.String < follows[j].FeedName.String
+	})
+
+	items := []sidebarItem{{kind: sidebarAll, label: "All", unread: total}}
+	folderIdx := -1
+	for _, follow := range follows {
+		folder := follow.Folder.String
+		if folder != "" && (folderIdx < 0 || items[folderIdx].folder != folder) {
+			items = append(items, sidebarItem{kind: sidebarFolder, label: folder, folder: folder})
+			folderIdx = len(items) - 1
+		}
+
+		count := unread[follow.FeedID.UUID]
+		if folder != "" {
+			items[folderIdx].unread += count
+		}
+		items = append(items, sidebarItem{
+			kind:   sidebarFeed,
+			label:  follow.FeedName.String,
+			feedID: follow.FeedID.UUID,
+			unread: count,
+			indent: folder != "",
+		})
+	}
+
+	t.sidebar = items
+	t.sidebarIdx = 0
+	for i, item := range items {
+		if item.key() == selected {
+			t.sidebarIdx = i
+		}
+	}
+}
+
+func (t *tui) loadPosts() {
+	ctx := t.s.ctx
+	userID := uuid.NullUUID{UUID: t.user.ID, Valid: true}
+	if len(t.sidebar) == 0 {
+		return
+	}
+	item := t.sidebar[t.sidebarIdx]
+
+	var posts []database.GetPostsWithStateForUserRow
+	var err error
+	switch item.kind {
+	case sidebarAll:
+		posts, err = t.s.db.GetPostsWithStateForUser(ctx, database.GetPostsWithStateForUserParams{
+			UserID: userID,
+			Limit:  tuiPostLimit,
+		})
+	case sidebarFolder:
+		var rows []database.GetPostsWithStateForFolderRow
+		rows, err = t.s.db.GetPostsWithStateForFolder(ctx, database.GetPostsWithStateForFolderParams{
+			UserID: userID,
+			Folder: sqlString(item.folder),
+			Limit:  tuiPostLimit,
+		})
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsWithStateForUserRow(row))
+		}
+	case sidebarFeed:
+		var rows []database.GetPostsWithStateForFeedRow
+		rows, err = t.s.db.GetPostsWithStateForFeed(ctx, database.GetPostsWithStateForFeedParams{
+			UserID: userID,
+			FeedID: uuid.NullUUID{UUID: item.feedID, Valid: true},
+			Limit:  tuiPostLimit,
+		})
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsWithStateForUserRow(row))
+		}
+	}
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to load posts: %v", err)
+		return
+	}
+
+	t.posts = posts
+	t.postIdx = clamp(t.postIdx, 0, len(posts)-1)
+}
+
+func (t *tui) sidebarWidth() int {
+	return clamp(t.width/4, 16, 32)
+}
+
+// listHeight is the number of post rows shown. The list takes the whole
+// right side until a post is opened.
+func (t *tui) listHeight() int {
+	body := t.height - 2
+	if !t.reading {
+		return body
+	}
+	return clamp(body*2/5, 3, body)
+}
+
+func (t *tui) readerHeight() int {
+	return t.height - 2 - t.listHeight() - 1
+}
+
+func (t *tui) readerLines() []string {
+	post := t.selectedPost()
+	if post == nil {
+		return nil
+	}
+
+	width := t.width - t.sidebarWidth() - 3
+	lines := []string{
+		"\033[1m" + post.Title.String + "\033[0m",
+		post.FeedName.String + " - " + post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04"),
+		post.Url.String,
+		"",
+	}
+	return append(lines, wrapText(stripHTML(post.Description.String), width)...)
+}
+
+func (t *tui) draw() {
+	sw := t.sidebarWidth()
+	rw := t.width - sw - 1
+	if rw < 1 {
+		return
+	}
+
+	// Keep the selections in view.
+	bodyHeight := t.height - 2
+	t.sidebarTop = scrollInto(t.sidebarIdx, t.sidebarTop, bodyHeight)
+	t.postTop = scrollInto(t.postIdx, t.postTop, t.listHeight())
+
+	left := make([]string, bodyHeight)
+	for y := range left {
+		i := t.sidebarTop + y
+		if i >= len(t.sidebar) {
+			left[y] = strings.Repeat(" ", sw)
+			continue
+		}
+		item := t.sidebar[i]
+		label := item.label
+		if item.indent {
+			label = "  " + label
+		}
+		if item.unread > 0 {
+			label = fmt.Sprintf("%s (%d)", label, item.unread)
+		}
+		left[y] = t.styleRow(fit(" "+label, sw), i == t.sidebarIdx, t.focus == paneSidebar)
+	}
+
+	right := make([]string, 0, bodyHeight)
+	for y := 0; y < t.listHeight(); y++ {
+		i := t.postTop + y
+		if i >= len(t.posts) {
+			right = append(right, strings.Repeat(" ", rw))
+			continue
+		}
+		post := t.posts[i]
+		mark := "●"
+		if post.ReadAt.Valid {
+			mark = " "
+		}
+		star := " "
+		if post.StarredAt.Valid {
+			star = "★"
+		}
+		line := fmt.Sprintf(" %s%s %s  %s", mark, star, post.PublishedAt.Time.Format("Jan 02"), post.Title.String)
+		right = append(right, t.styleRow(fit(line, rw), i == t.postIdx, t.focus == panePosts))
+	}
+	if t.reading {
+		right = append(right, strings.Repeat("─", rw))
+		lines := t.readerLines()
+		for y := 0; y < t.readerHeight(); y++ {
+			i := t.readerTop + y
+			if i >= len(lines) {
+				right = append(right, strings.Repeat(" ", rw))
+				continue
+			}
+			right = append(right, fitStyled(" "+lines[i], rw))
+		}
+	}
+
+	b := strings.Builder{}
+	b.WriteString("\033[H")
+	title := fmt.Sprintf(" gator - %s", t.user.Name)
+	b.WriteString("\033[7m" + fit(title, t.width) + "\033[0m\r\n")
+	for y := 0; y < bodyHeight; y++ {
+		b.WriteString(left[y] + "│" + right[y] + "\r\n")
+	}
+	help := " tab/h/l: pane  j/k: move  enter: open  r: read  s: star  R: refresh  q: quit"
+	if t.status != "" {
+		help = " " + t.status
+	}
+	b.WriteString("\033[7m" + fit(help, t.width) + "\033[0m")
+	fmt.Print(b.String())
+}
+
+// styleRow highlights the selected row, dimmer when its pane isn't focused.
+func (t *tui) styleRow(line string, selected bool, focused bool) string {
+	switch {
+	case selected && focused:
+		return "\033[7m" + line + "\033[0m"
+	case selected:
+		return "\033[1m" + line + "\033[0m"
+	}
+	return line
+}
+
+// fit cuts or pads s to exactly width columns.
+func fit(s string, width int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return ' '
+		}
+		return r
+	}, s)
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// fitStyled is fit for lines that may contain a single leading style and a
+// trailing reset.
+func fitStyled(s string, width int) string {
+	plain := strings.NewReplacer("\033[1m", "", "\033[0m", "").Replace(s)
+	if plain == s {
+		return fit(s, width)
+	}
+	return "\033[1m" + fit(plain, width) + "\033[0m"
+}
+
+func wrapText(s string, width int) []string {
+	if width < 10 {
+		width = 10
+	}
+
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// scrollInto returns the first visible row so that idx is inside a view
+// of the given height.
+func scrollInto(idx, top, height int) int {
+	if idx < top {
+		return idx
+	}
+	if height > 0 && idx >= top+height {
+		return idx - height + 1
+	}
+	return top
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
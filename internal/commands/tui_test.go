@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+// newTestTUI returns the tui of alice, following Blog with two posts and
+// News, in the news folder, with one.
+func newTestTUI(t *testing.T) *tui {
+	t.Helper()
+	s := newTestState(t)
+	now := time.Now()
+	blog := newFeedServer(t,
+		rssItem{"Blog post", now.Add(-time.Hour)},
+		rssItem{"Older blog post", now.Add(-2 * time.Hour)},
+	)
+	news := newFeedServer(t, rssItem{"News post", now.Add(-3 * time.Hour)})
+
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Blog", blog.URL+"/feed.xml")
+	mustRun(t, s, "", "addfeed", "News", news.URL+"/feed.xml")
+	mustRun(t, s, "", "folder", news.URL+"/feed.xml", "news")
+	mustRun(t, s, "", "agg", "--once")
+
+	user, err := s.db.GetUser(s.ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := &tui{s: s, user: user, width: 100, height: 20}
+	ui.reload()
+	return ui
+}
+
+func sidebarLabels(ui *tui) []string {
+	labels := []string{}
+	for _, item := range ui.sidebar {
+		labels = append(labels, item.label+" "+strings.Repeat("*", int(item.unread)))
+	}
+	return labels
+}
+
+func postTitles(ui *tui) []string {
+	titles := []string{}
+	for _, post := range ui.posts {
+		titles = append(titles, post.Title.String)
+	}
+	return titles
+}
+
+func TestTUISidebar(t *testing.T) {
+	ui := newTestTUI(t)
+
+	want := []string{"All ***", "Blog **", "news *", "News *"}
+	if got := sidebarLabels(ui); !slices.Equal(got, want) {
+		t.Errorf("the sidebar is %q, want %q", got, want)
+	}
+	if got := postTitles(ui); !slices.Equal(got, []string{"Blog post", "Older blog post", "News post"}) {
+		t.Errorf("All shows %q", got)
+	}
+
+	// Open the folder, then the feed in it.
+	for _, key := range []string{"j", "j", "\r"} {
+		ui.handleKey(key)
+	}
+	if got := postTitles(ui); !slices.Equal(got, []string{"News post"}) {
+		t.Errorf("the news folder shows %q", got)
+	}
+	if ui.focus != panePosts {
+		t.Errorf("enter on the sidebar focused pane %d, want the posts", ui.focus)
+	}
+	for _, key := range []string{"h", "k", "\r"} {
+		ui.handleKey(key)
+	}
+	if got := postTitles(ui); !slices.Equal(got, []string{"Blog post", "Older blog post"}) {
+		t.Errorf("Blog shows %q", got)
+	}
+}
+
+func TestTUIReadAndStar(t *testing.T) {
+	ui := newTestTUI(t)
+	ui.focus = panePosts
+
+	// Opening a post marks it read.
+	ui.handleKey("j")
+	ui.handleKey("\r")
+	if !ui.reading || ui.focus != paneReader {
+		t.Fatal("enter on a post didn't open it")
+	}
+	if got := sidebarLabels(ui); got[0] != "All **" || got[1] != "Blog *" {
+		t.Errorf("after reading a post the sidebar is %q", got)
+	}
+	ui.handleKey("\033")
+	if ui.reading || ui.focus != panePosts {
+		t.Error("escape didn't close the post")
+	}
+
+	ui.handleKey("s")
+	ui.handleKey("r")
+	ui.handleKey("r")
+	ui.handleKey("R")
+
+	states, err := ui.s.db.GetPostsWithStateForUser(ui.s.ctx, database.GetPostsWithStateForUserParams{
+		UserID: uuid.NullUUID{UUID: ui.user.ID, Valid: true},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, post := range states {
+		wantRead := post.Title.String == "Older blog post"
+		if post.ReadAt.Valid != wantRead || post.StarredAt.Valid != wantRead {
+			t.Errorf("%s: read %v and starred %v, want %v", post.Title.String, post.ReadAt.Valid, post.StarredAt.Valid, wantRead)
+		}
+	}
+	// The selection survives the refresh.
+	if post := ui.selectedPost(); post == nil || post.Title.String != "Older blog post" {
+		t.Errorf("after R the selected post is %v", post)
+	}
+}
+
+func TestTUIDraw(t *testing.T) {
+	ui := newTestTUI(t)
+	ui.focus = panePosts
+	ui.handleKey("\r")
+	ui.handleKey("s")
+
+	output, err := captureOutput(t, func() error {
+		ui.draw()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(output, "\r\n")
+	if len(rows) != ui.height {
+		t.Errorf("draw printed %d rows, want %d", len(rows), ui.height)
+	}
+	for _, text := range []string{"gator - alice", "All (2)", "Blog (1)", "★", "Blog post", "example.com/Blog-post", "q: quit"} {
+		if !strings.Contains(output, text) {
+			t.Errorf("draw didn't print %q", text)
+		}
+	}
+}
+
+func TestTUINeedsTerminal(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+	if _, err := runCommand(t, s, "", "tui"); err == nil || !strings.Contains(err.Error(), "interactive terminal") {
+		t.Errorf("tui without a terminal returned %v", err)
+	}
+}
+
+func TestTUILayout(t *testing.T) {
+	if got := fit("a\tb", 5); got != "a b  " {
+		t.Errorf("fit padded to %q", got)
+	}
+	if got := fit("héllo wörld", 5); got != "héllo" {
+		t.Errorf("fit cut to %q", got)
+	}
+	if got := wrapText("the quick brown fox jumps over the lazy dog", 15); !slices.Equal(got, []string{"the quick brown", "fox jumps over", "the lazy dog"}) {
+		t.Errorf("wrapText = %q", got)
+	}
+	for _, test := range []struct{ idx, top, height, want int }{
+		{0, 5, 10, 0},
+		{7, 5, 10, 5},
+		{20, 5, 10, 11},
+	} {
+		if got := scrollInto(test.idx, test.top, test.height); got != test.want {
+			t.Errorf("scrollInto(%d, %d, %d) = %d, want %d", test.idx, test.top, test.height, got, test.want)
+		}
+	}
+}
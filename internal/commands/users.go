@@ -1,14 +1,22 @@
 package commands
 
 import (
-	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/babanini95/gatorcli/internal/database"
 	"github.com/google/uuid"
 )
 
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
 func handlerLogin(s *state, cmd command) error {
 	if len(cmd.arguments) == 0 {
 		return fmt.Errorf("login command expected an argument")
@@ -20,7 +28,31 @@ func handlerLogin(s *state, cmd command) error {
 		os.Exit(1)
 	}
 
-	err := s.cfg.SetUser(userName)
+	ctx := s.ctx
+	user, err := s.db.GetUser(ctx, userName)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash.Valid {
+		password, err := readPassword("Password: ")
+		if err != nil {
+			return err
+		}
+		if err := checkPassword(user, password); err != nil {
+			return err
+		}
+	}
+
+	// Replace the session of whoever was logged in before.
+	if s.cfg.SessionToken != "" {
+		s.db.DeleteSession(ctx, hashToken(s.cfg.SessionToken))
+	}
+	token, err := createSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	err = s.cfg.SetSession(userName, token)
 	if err != nil {
 		return err
 	}
@@ -38,54 +70,296 @@ func handlerRegister(s *state, cmd command) error {
 		os.Exit(1)
 	}
 
+	password, err := readNewPassword(newPasswordPrompt)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	userParams := database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: sqlCurrentTime(),
-		UpdatedAt: sqlCurrentTime(),
-		Name:      userName,
+		ID:           uuid.New(),
+		CreatedAt:    sqlCurrentTime(),
+		UpdatedAt:    sqlCurrentTime(),
+		Name:         userName,
+		PasswordHash: passwordHash,
 	}
-	user, err := s.db.CreateUser(context.Background(), userParams)
+	user, err := s.db.CreateUser(s.ctx, userParams)
 	if err != nil {
 		return fmt.Errorf("create user failed: %v", err)
 	}
 
-	err = s.cfg.SetUser(user.Name)
+	token, err := createSession(s.ctx, s, user)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("User %s has been created. User data:\n%v\n", userName, user)
+	err = s.cfg.SetSession(user.Name, token)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("User %s has been created. User data:\n%v\n", userName, newUserRecord(user))
 	return nil
 }
 
-func handlerReset(s *state, cmd command) error {
-	err := s.db.DeleteAllUser(context.Background())
+// handlerReset deletes every user, and with them all feeds, follows and
+// posts. --feeds-only keeps the users, --posts-only keeps users and feeds.
+func handlerReset(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("reset", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	postsOnly := flags.Bool("posts-only", false, "only delete posts")
+	feedsOnly := flags.Bool("feeds-only", false, "only delete feeds and their posts")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("reset: %v", err)
+	}
+	if *postsOnly && *feedsOnly {
+		return fmt.Errorf("reset: --posts-only and --feeds-only can not be combined")
+	}
+
+	ctx := s.ctx
+	counts, err := s.db.CountRows(ctx)
 	if err != nil {
-		os.Exit(1)
+		return fmt.Errorf("failed to count rows: %v", err)
+	}
+	switch {
+	case *postsOnly:
+		fmt.Printf("This will delete %d posts with their read and starred marks. Feeds will be fetched again.\n", counts.Posts)
+	case *feedsOnly:
+		fmt.Printf("This will delete %d feeds, %d follows and %d posts.\n", counts.Feeds, counts.Follows, counts.Posts)
+	default:
+		fmt.Printf("This will delete %d users, %d feeds, %d follows and %d posts.\n", counts.Users, counts.Feeds, counts.Follows, counts.Posts)
+	}
+	if err := confirm(*yes); err != nil {
 		return err
 	}
-	fmt.Println("users database reset successfully")
-	os.Exit(0)
+
+	switch {
+	case *postsOnly:
+		if err := s.db.DeleteAllPosts(ctx); err != nil {
+			return fmt.Errorf("failed to delete posts: %v", err)
+		}
+		if err := s.db.ResetFeedsFetched(ctx); err != nil {
+			return fmt.Errorf("failed to reset feeds: %v", err)
+		}
+		fmt.Println("posts deleted successfully")
+	case *feedsOnly:
+		if err := s.db.DeleteAllFeeds(ctx); err != nil {
+			return fmt.Errorf("failed to delete feeds: %v", err)
+		}
+		fmt.Println("feeds deleted successfully")
+	default:
+		if err := s.db.DeleteAllUser(ctx); err != nil {
+			return fmt.Errorf("failed to delete users: %v", err)
+		}
+		fmt.Println("users database reset successfully")
+	}
 	return nil
 }
 
-func handlerUsers(s *state, cmd command) error {
-	users, err := s.db.GetUsers(context.Background())
+func handlerUserDelete(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("user delete", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("user delete: %v", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("user delete command expected a user name")
+	}
+
+	name := flags.Arg(0)
+	if name != user.Name && user.Role != roleAdmin {
+		return fmt.Errorf("only admins can delete other users")
+	}
+
+	ctx := s.ctx
+	target, err := getUser(s, name)
 	if err != nil {
-		return fmt.Errorf("failed to get all users: %v", err)
+		return err
+	}
+	if err := checkNotLastAdmin(s, target); err != nil {
+		return err
 	}
 
-	for _, user := range users {
-		currentUserMark := ""
-		if s.cfg.CurrentUserName == user.Name {
-			currentUserMark = "(current)"
+	counts, err := s.db.CountRowsForUser(ctx, uuid.NullUUID{UUID: target.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to count rows: %v", err)
+	}
+	fmt.Printf("This will delete user %s, their %d follows and the %d feeds they added with %d posts.\n",
+		name, counts.Follows, counts.Feeds, counts.Posts)
+	if counts.OtherFollows > 0 {
+		fmt.Printf("Those feeds are also followed %d times by other users.\n", counts.OtherFollows)
+	}
+	if err := confirm(*yes); err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteUser(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if name == s.cfg.CurrentUserName {
+		if err := s.cfg.SetSession("", ""); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("User %s has been deleted\n", name)
+	return nil
+}
+
+func handlerUserRename(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("user rename command expected the old and the new name")
+	}
+
+	oldName, newName := cmd.arguments[0], cmd.arguments[1]
+	if oldName != user.Name && user.Role != roleAdmin {
+		return fmt.Errorf("only admins can rename other users")
+	}
+	target, err := getUser(s, oldName)
+	if err != nil {
+		return err
+	}
+	if isUserExist(s, newName) {
+		return fmt.Errorf("user %s already exists", newName)
+	}
+
+	err = s.db.RenameUser(s.ctx, database.RenameUserParams{
+		NewName:   newName,
+		UpdatedAt: sqlCurrentTime(),
+		OldName:   oldName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename user: %v", err)
+	}
+	if oldName == s.cfg.CurrentUserName {
+		if err := s.cfg.SetSession(newName, s.cfg.SessionToken); err != nil {
+			return err
 		}
-		fmt.Printf("* %s %s\n", user.Name, currentUserMark)
 	}
 
+	fmt.Printf("User %s has been renamed to %s\n", oldName, newName)
+	if target.FeverKey.Valid {
+		// The Fever key is derived from the name.
+		fmt.Println("Run gator fever again to keep using the Fever API")
+	}
 	return nil
 }
 
+func handlerUserPromote(s *state, cmd command, user database.User) error {
+	return setUserRole(s, cmd, roleAdmin)
+}
+
+func handlerUserDemote(s *state, cmd command, user database.User) error {
+	return setUserRole(s, cmd, roleMember)
+}
+
+func setUserRole(s *state, cmd command, role string) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("user command expected a user name")
+	}
+
+	target, err := getUser(s, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	if target.Role == role {
+		fmt.Printf("User %s is already %s\n", target.Name, role)
+		return nil
+	}
+	if err := checkNotLastAdmin(s, target); err != nil {
+		return err
+	}
+
+	err = s.db.SetUserRole(s.ctx, database.SetUserRoleParams{
+		Role:      role,
+		UpdatedAt: sqlCurrentTime(),
+		ID:        target.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set role: %v", err)
+	}
+	fmt.Printf("User %s is now %s\n", target.Name, role)
+	return nil
+}
+
+// checkNotLastAdmin keeps the database from ending up without an admin
+// when an admin is demoted or deleted.
+func checkNotLastAdmin(s *state, user database.User) error {
+	if user.Role != roleAdmin {
+		return nil
+	}
+	admins, err := s.db.CountAdmins(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %v", err)
+	}
+	if admins <= 1 {
+		return fmt.Errorf("%s is the last admin, promote another user first", user.Name)
+	}
+	return nil
+}
+
+func handlerUsers(s *state, cmd command, user database.User) error {
+	users, err := s.db.GetUsers(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get all users: %v", err)
+	}
+
+	return s.render(userRecords(users), func() {
+		for _, user := range users {
+			marks := ""
+			if user.Role == roleAdmin {
+				marks += " (admin)"
+			}
+			if s.cfg.CurrentUserName == user.Name {
+				marks += " (current)"
+			}
+			fmt.Printf("* %s%s\n", user.Name, marks)
+		}
+	})
+}
+
+func getUser(s *state, name string) (database.User, error) {
+	user, err := s.db.GetUser(s.ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, fmt.Errorf("user %s not found", name)
+	}
+	if err != nil {
+		return user, fmt.Errorf("failed to get user: %v", err)
+	}
+	return user, nil
+}
+
 func isUserExist(s *state, userName string) bool {
-	u, _ := s.db.GetUser(context.Background(), userName)
+	u, _ := s.db.GetUser(s.ctx, userName)
 	return u.ID != uuid.Nil
 }
+
+// userRecord is the part of a user that is shown in listings, without the
+// password hash and Fever key.
+type userRecord struct {
+	ID        uuid.UUID
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	Name      string
+	Role      string
+}
+
+func newUserRecord(user database.User) userRecord {
+	return userRecord{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Name:      user.Name,
+		Role:      user.Role,
+	}
+}
+
+func userRecords(users []database.User) []userRecord {
+	records := make([]userRecord, 0, len(users))
+	for _, user := range users {
+		records = append(records, newUserRecord(user))
+	}
+	return records
+}
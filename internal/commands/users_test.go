@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReset(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	feedURL := newFeedServer(t,
+		rssItem{"First post", now.Add(-2 * time.Hour)},
+		rssItem{"Second post", now.Add(-time.Hour)},
+	).URL + "/feed.xml"
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", feedURL)
+	mustRun(t, s, "", "agg", "--once")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "follow", feedURL)
+
+	if _, err := runCommand(t, s, "", "reset", "--yes"); err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("reset as a member: %v", err)
+	}
+	mustRun(t, s, "", "login", "alice")
+
+	// Without a terminal to confirm on, reset only says what it would delete.
+	output, err := runCommand(t, s, "yes\n", "reset")
+	if err == nil || !strings.Contains(err.Error(), "pass --yes") {
+		t.Errorf("reset without --yes: %v", err)
+	}
+	if !strings.Contains(output, "This will delete 2 users, 1 feeds, 2 follows and 2 posts.") {
+		t.Errorf("reset printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "reset", "--posts-only", "--feeds-only", "--yes"); err == nil {
+		t.Error("reset with both scopes succeeded")
+	}
+
+	output = mustRun(t, s, "", "reset", "--posts-only", "--yes")
+	if !strings.Contains(output, "This will delete 2 posts") {
+		t.Errorf("reset --posts-only printed %q", output)
+	}
+	if output := mustRun(t, s, "", "browse", "10"); output != "" {
+		t.Errorf("after reset --posts-only browse printed %q", output)
+	}
+	feed, err := s.db.GetFeedByUrl(s.ctx, sqlString(feedURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.LastFetchedAt.Valid {
+		t.Error("reset --posts-only kept the feed marked fetched")
+	}
+
+	output = mustRun(t, s, "", "reset", "--feeds-only", "--yes")
+	if !strings.Contains(output, "This will delete 1 feeds, 2 follows and 0 posts.") {
+		t.Errorf("reset --feeds-only printed %q", output)
+	}
+	if output := mustRun(t, s, "", "following"); output != "" {
+		t.Errorf("after reset --feeds-only alice follows %q", output)
+	}
+
+	mustRun(t, s, "", "reset", "--yes")
+	users, err := s.db.GetUsers(s.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("reset left %d users", len(users))
+	}
+}
+
+func TestUserDeleteAndRename(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "register", "bob")
+	mustRun(t, s, "", "addfeed", "Blog", "https://example.com/feed.xml")
+	mustRun(t, s, "", "login", "alice")
+	mustRun(t, s, "", "follow", "https://example.com/feed.xml")
+
+	// Members can only change themselves.
+	mustRun(t, s, "", "login", "bob")
+	if _, err := runCommand(t, s, "", "user", "delete", "--yes", "alice"); err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("bob deleting alice: %v", err)
+	}
+	if _, err := runCommand(t, s, "", "user", "rename", "alice", "carol"); err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("bob renaming alice: %v", err)
+	}
+	if _, err := runCommand(t, s, "", "user", "rename", "bob", "alice"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("renaming bob to alice: %v", err)
+	}
+
+	mustRun(t, s, "", "user", "rename", "bob", "robert")
+	if s.cfg.CurrentUserName != "robert" {
+		t.Errorf("after renaming bob the current user is %q", s.cfg.CurrentUserName)
+	}
+	if output := mustRun(t, s, "", "following"); output != "Blog\n" {
+		t.Errorf("robert follows %q, want Blog", output)
+	}
+
+	mustRun(t, s, "", "login", "alice")
+	output, err := runCommand(t, s, "", "user", "delete", "robert")
+	if err == nil {
+		t.Error("user delete without --yes succeeded")
+	}
+	for _, want := range []string{
+		"This will delete user robert, their 1 follows and the 1 feeds they added with 0 posts.",
+		"Those feeds are also followed 1 times by other users.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("user delete printed %q, want %q", output, want)
+		}
+	}
+
+	mustRun(t, s, "", "user", "delete", "--yes", "robert")
+	if isUserExist(s, "robert") {
+		t.Error("robert still exists")
+	}
+	// The feeds of a deleted user go with them, for their followers too.
+	if output := mustRun(t, s, "", "following"); output != "" {
+		t.Errorf("alice follows %q after robert's feed was deleted", output)
+	}
+
+	if _, err := runCommand(t, s, "", "user", "delete", "--yes", "nobody"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("deleting an unknown user: %v", err)
+	}
+
+	// Deleting yourself logs you out.
+	mustRun(t, s, "", "register", "carol")
+	mustRun(t, s, "", "user", "delete", "--yes", "carol")
+	if s.cfg.CurrentUserName != "" || s.cfg.SessionToken != "" {
+		t.Errorf("after deleting carol the config has user %q", s.cfg.CurrentUserName)
+	}
+}
+
+func TestUserRoles(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "register", "bob")
+
+	for _, args := range [][]string{{"users"}, {"user", "promote", "bob"}, {"user", "demote", "alice"}} {
+		if _, err := runCommand(t, s, "", args[0], args[1:]...); err == nil || !strings.Contains(err.Error(), "only admins") {
+			t.Errorf("bob ran %q: %v", args, err)
+		}
+	}
+
+	mustRun(t, s, "", "login", "alice")
+	if output := mustRun(t, s, "", "users"); output != "* alice (admin) (current)\n* bob\n" {
+		t.Errorf("users printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "user", "demote", "alice"); err == nil || !strings.Contains(err.Error(), "last admin") {
+		t.Errorf("demoting the last admin: %v", err)
+	}
+	if _, err := runCommand(t, s, "", "user", "delete", "--yes", "alice"); err == nil || !strings.Contains(err.Error(), "last admin") {
+		t.Errorf("deleting the last admin: %v", err)
+	}
+
+	if output := mustRun(t, s, "", "user", "promote", "bob"); output != "User bob is now admin\n" {
+		t.Errorf("user promote printed %q", output)
+	}
+	if output := mustRun(t, s, "", "user", "promote", "bob"); output != "User bob is already admin\n" {
+		t.Errorf("promoting bob again printed %q", output)
+	}
+	if _, err := runCommand(t, s, "", "user", "promote", "nobody"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("promoting an unknown user: %v", err)
+	}
+
+	// With bob as an admin, alice can step down, and then can't undo it.
+	mustRun(t, s, "", "user", "demote", "alice")
+	if role := mustGetUser(t, s, "alice").Role; role != roleMember {
+		t.Errorf("alice is %s after demote, want %s", role, roleMember)
+	}
+	if _, err := runCommand(t, s, "", "user", "promote", "alice"); err == nil {
+		t.Error("alice promoted alice after being demoted")
+	}
+	mustRun(t, s, "", "login", "bob")
+	if _, err := runCommand(t, s, "", "user", "demote", "bob"); err == nil || !strings.Contains(err.Error(), "last admin") {
+		t.Errorf("demoting bob, now the last admin: %v", err)
+	}
+}
@@ -1,13 +1,27 @@
 package commands
 
 import (
+	"bufio"
 	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
+
+	"golang.org/x/term"
 )
 
+// sqlCurrentTime and sqlTime bind times in UTC. SQLite stores them as
+// text, which only sorts and compares like the times it holds when they
+// share an offset.
 func sqlCurrentTime() sql.NullTime {
+	return sqlTime(time.Now())
+}
+
+func sqlTime(t time.Time) sql.NullTime {
 	return sql.NullTime{
-		Time:  time.Now(),
+		Time:  t.UTC(),
 		Valid: true,
 	}
 }
@@ -18,3 +32,48 @@ func sqlString(s string) sql.NullString {
 		Valid:  s != "",
 	}
 }
+
+// likeEscaper escapes the LIKE wildcards with \, the ESCAPE character of
+// the search queries.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern returns a LIKE pattern that matches text anywhere, taking
+// % and _ in text literally.
+func containsPattern(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+// isDuplicate reports whether err is a unique constraint violation, as
+// reported by PostgreSQL or SQLite.
+func isDuplicate(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "duplicate key") ||
+		strings.Contains(errStr, "UNIQUE constraint failed")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// confirm asks the user to type "yes" before a destructive change, unless
+// it was already confirmed with --yes.
+func confirm(yes bool) error {
+	if yes {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("stdin is not a terminal, pass --yes to confirm")
+	}
+
+	fmt.Print("Type \"yes\" to continue: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		return fmt.Errorf("aborted")
+	}
+	return nil
+}
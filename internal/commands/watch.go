@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const newPostChannel = "gator_new_post"
+
+// catchUpOverlap is how far before the newest post printed catchUp looks
+// for new ones. agg saves posts with the time it fetched them, but they
+// only show up once its transaction commits, so a post can turn up after
+// newer ones were printed.
+const catchUpOverlap = 5 * time.Minute
+
+type watchFilter struct {
+	feedURL string
+	folder  string
+	match   string
+}
+
+func (f watchFilter) allows(post database.GetPostForUserRow) bool {
+	if f.feedURL != "" && post.FeedUrl.String != f.feedURL {
+		return false
+	}
+	if f.folder != "" && post.Folder.String != f.folder {
+		return false
+	}
+	if f.match != "" {
+		match := strings.ToLower(f.match)
+		if !strings.Contains(strings.ToLower(post.Title.String), match) &&
+			!strings.Contains(strings.ToLower(post.Description.String), match) {
+			return false
+		}
+	}
+	return true
+}
+
+func handlerWatch(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	filter := watchFilter{}
+	flags.StringVar(&filter.feedURL, "feed", "", "only show posts from this feed url")
+	flags.StringVar(&filter.folder, "folder", "", "only show posts from feeds in this folder")
+	flags.StringVar(&filter.match, "match", "", "only show posts containing this text")
+	poll := flags.Bool("poll", false, "poll for new posts instead of using LISTEN/NOTIFY")
+	interval := flags.Duration("interval", 5*time.Second, "time between polls")
+	if err := flags.Parse(cmd.arguments); err != nil {
+		return fmt.Errorf("watch: %v", err)
+	}
+
+	now := time.Now()
+	w := &watcher{
+		s:      s,
+		userID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		filter: filter,
+		start:  now,
+		since:  now,
+		seen:   map[uuid.UUID]time.Time{},
+	}
+
+	// Only PostgreSQL can notify us of new posts.
+	if !*poll && s.driver == "postgres" {
+		err := w.listen()
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("Can not listen for new posts, polling instead: %v\n", err)
+	}
+	return w.poll(*interval)
+}
+
+type watcher struct {
+	s      *state
+	userID uuid.NullUUID
+	filter watchFilter
+	// start is when watch started, since is when the newest post printed
+	// was created and seen are the posts created within catchUpOverlap of
+	// it, so they are only printed once.
+	start time.Time
+	since time.Time
+	seen  map[uuid.UUID]time.Time
+}
+
+// listen waits for the notifications sent by the posts insert trigger. It
+// only returns when the listener can't be set up or the command is stopped.
+func (w *watcher) listen() error {
+	failed := make(chan error, 1)
+	listener := pq.NewListener(w.s.cfg.DbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if event == pq.ListenerEventConnectionAttemptFailed {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	})
+	defer listener.Close()
+
+	// Listen blocks until the listener is connected, so give up as soon as
+	// the first connection attempt fails.
+	listened := make(chan error, 1)
+	go func() {
+		listened <- listener.Listen(newPostChannel)
+	}()
+	select {
+	case err := <-failed:
+		return err
+	case err := <-listened:
+		if err != nil {
+			return err
+		}
+	case <-w.s.ctx.Done():
+		return nil
+	}
+	fmt.Println("Watching for new posts...")
+
+	for {
+		select {
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			// and notifications may have been missed.
+			if n == nil {
+				w.catchUp()
+				continue
+			}
+
+			id, err := uuid.Parse(n.Extra)
+			if err != nil {
+				continue
+			}
+			post, err := w.s.db.GetPostForUser(w.s.ctx, database.GetPostForUserParams{
+				UserID: w.userID,
+				ID:     id,
+			})
+			if err != nil {
+				// Not a post from a feed we follow.
+				continue
+			}
+			w.print(post)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		case <-w.s.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// poll checks for posts created since the last one printed, or a little
+// before, see catchUpOverlap.
+func (w *watcher) poll(interval time.Duration) error {
+	fmt.Printf("Watching for new posts every %s...\n", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.catchUp(); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-w.s.ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (w *watcher) catchUp() error {
+	after := w.since.Add(-catchUpOverlap)
+	if after.Before(w.start) {
+		after = w.start
+	}
+	posts, err := w.s.db.GetPostsCreatedAfterForUser(w.s.ctx, database.GetPostsCreatedAfterForUserParams{
+		UserID:    w.userID,
+		CreatedAt: sqlTime(after),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get new posts: %v", err)
+	}
+
+	for _, post := range posts {
+		w.print(database.GetPostForUserRow(post))
+	}
+	for id, createdAt := range w.seen {
+		if createdAt.Before(w.since.Add(-catchUpOverlap)) {
+			delete(w.seen, id)
+		}
+	}
+	return nil
+}
+
+func (w *watcher) print(post database.GetPostForUserRow) {
+	if _, ok := w.seen[post.ID]; ok {
+		return
+	}
+	w.seen[post.ID] = post.CreatedAt.Time
+	if post.CreatedAt.Time.After(w.since) {
+		w.since = post.CreatedAt.Time
+	}
+	if !w.filter.allows(post) {
+		return
+	}
+
+	w.s.render([]database.GetPostForUserRow{post}, func() {
+		fmt.Printf("[%s] %s: %s\n  %s\n",
+			post.PublishedAt.Time.Format("2006-01-02 15:04"),
+			post.FeedName.String,
+			post.Title.String,
+			post.Url.String,
+		)
+	})
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestWatchCatchUp(t *testing.T) {
+	s := newTestState(t)
+	server := newFeedServer(t)
+	mustRun(t, s, "", "register", "alice")
+	mustRun(t, s, "", "addfeed", "Test", server.URL+"/feed.xml")
+
+	user, err := s.db.GetUser(s.ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := s.db.GetFeedByUrl(s.ctx, sql.NullString{String: server.URL + "/feed.xml", Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	createPost := func(title string, createdAt time.Time) {
+		t.Helper()
+		err := s.db.CreatePost(s.ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   sqlTime(createdAt),
+			UpdatedAt:   sqlTime(createdAt),
+			Title:       sql.NullString{String: title, Valid: true},
+			Url:         sql.NullString{String: "https://example.com/" + title, Valid: true},
+			PublishedAt: sqlTime(createdAt),
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &watcher{
+		s:      s,
+		userID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		start:  start,
+		since:  start,
+		seen:   map[uuid.UUID]time.Time{},
+	}
+	catchUp := func() string {
+		t.Helper()
+		output, err := captureOutput(t, w.catchUp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	createPost("before", start.Add(-time.Minute))
+	createPost("first", start.Add(2*time.Minute))
+	if output := catchUp(); strings.Contains(output, "before") || !strings.Contains(output, "first") {
+		t.Errorf("catchUp printed %q, want only the post created after watch started", output)
+	}
+
+	// A post saved earlier than the one printed, but committed after it.
+	createPost("late", start.Add(time.Minute))
+	if output := catchUp(); strings.Contains(output, "first") || !strings.Contains(output, "late") {
+		t.Errorf("catchUp printed %q, want only the late post", output)
+	}
+	if output := catchUp(); output != "" {
+		t.Errorf("catchUp printed %q again", output)
+	}
+
+	// Posts older than the overlap are forgotten, the newer ones are kept
+	// so they aren't printed twice.
+	createPost("much later", start.Add(catchUpOverlap+90*time.Second))
+	if output := catchUp(); !strings.Contains(output, "much later") {
+		t.Errorf("catchUp printed %q, want the new post", output)
+	}
+	if len(w.seen) != 2 {
+		t.Errorf("watch remembers %d posts, want 2", len(w.seen))
+	}
+}
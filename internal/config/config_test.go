@@ -0,0 +1,290 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setHome points the home and config directories at temporary ones and
+// clears the GATOR_* variables, and returns the home directory.
+func setHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	for _, name := range []string{"GATOR_DB_URL", "GATOR_USER", "GATOR_SESSION_TOKEN", "GATOR_PROFILE", "GATOR_OUTPUT", "GATOR_TIMEOUT"} {
+		t.Setenv(name, "")
+	}
+	return home
+}
+
+func writeConfig(t *testing.T, path string, c any) {
+	t.Helper()
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustRead(t *testing.T) *Config {
+	t.Helper()
+	c, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPath(t *testing.T) {
+	home := setHome(t)
+	xdgPath := filepath.Join(home, "xdg", "gator", "config.json")
+	legacyPath := filepath.Join(home, ".gatorconfig.json")
+
+	check := func(want string) {
+		t.Helper()
+		got, err := Path()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Path() = %s, want %s", got, want)
+		}
+	}
+
+	check(xdgPath)
+	writeConfig(t, legacyPath, map[string]string{"db_url": "memory://"})
+	check(legacyPath)
+	writeConfig(t, xdgPath, map[string]string{"db_url": "memory://"})
+	check(xdgPath)
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	check(legacyPath)
+	defaultPath := filepath.Join(home, ".config", "gator", "config.json")
+	writeConfig(t, defaultPath, map[string]string{"db_url": "memory://"})
+	check(defaultPath)
+}
+
+func TestReadPrecedence(t *testing.T) {
+	home := setHome(t)
+
+	c := mustRead(t)
+	if c.DbURL != DefaultDbURL {
+		t.Errorf("db_url without a config file is %s, want %s", c.DbURL, DefaultDbURL)
+	}
+	if _, ok := c.Timeout("feeds"); ok {
+		t.Error("a timeout is set without a config file")
+	}
+
+	writeConfig(t, filepath.Join(home, "xdg", "gator", "config.json"), map[string]any{
+		"db_url":            "sqlite:file.db",
+		"current_user_name": "alice",
+		"session_token":     "alice-token",
+		"timeouts":          map[string]string{"feeds": "10s"},
+	})
+	c = mustRead(t)
+	if c.DbURL != "sqlite:file.db" || c.CurrentUserName != "alice" || c.SessionToken != "alice-token" {
+		t.Errorf("the file's settings are not used: %+v", c.Profile)
+	}
+	if timeout, _ := c.Timeout("feeds"); timeout != 10*time.Second {
+		t.Errorf("the timeout from the file is %s, want 10s", timeout)
+	}
+
+	t.Setenv("GATOR_DB_URL", "sqlite:env.db")
+	t.Setenv("GATOR_USER", "bob")
+	t.Setenv("GATOR_TIMEOUT", "20s")
+	c = mustRead(t)
+	if c.DbURL != "sqlite:env.db" || c.CurrentUserName != "bob" {
+		t.Errorf("the environment doesn't override the file: %+v", c.Profile)
+	}
+	if c.SessionToken != "" {
+		t.Error("GATOR_USER kept the session of the user in the file")
+	}
+	if timeout, _ := c.Timeout("feeds"); timeout != 20*time.Second {
+		t.Errorf("the timeout from the environment is %s, want 20s", timeout)
+	}
+
+	c.Override(Profile{DbURL: "memory://", CurrentUserName: "carol"})
+	if err := c.OverrideTimeout("30s"); err != nil {
+		t.Fatal(err)
+	}
+	if c.DbURL != "memory://" || c.CurrentUserName != "carol" {
+		t.Errorf("the flags don't override the environment: %+v", c.Profile)
+	}
+	if timeout, _ := c.Timeout("feeds"); timeout != 30*time.Second {
+		t.Errorf("the timeout from the flags is %s, want 30s", timeout)
+	}
+
+	// Neither the environment nor the flags are written to the file.
+	if err := c.Set("templates.short", "{{.Title}}"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GATOR_DB_URL", "")
+	t.Setenv("GATOR_USER", "")
+	c = mustRead(t)
+	if c.DbURL != "sqlite:file.db" || c.CurrentUserName != "alice" || c.SessionToken != "alice-token" {
+		t.Errorf("the overrides were saved: %+v", c.Profile)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	home := setHome(t)
+	writeConfig(t, filepath.Join(home, "xdg", "gator", "config.json"), map[string]any{
+		"db_url":            "sqlite:personal.db",
+		"current_user_name": "alice",
+		"current_profile":   "work",
+		"profiles": map[string]any{
+			"work": map[string]string{"db_url": "sqlite:work.db", "current_user_name": "bob"},
+		},
+	})
+
+	c := mustRead(t)
+	if c.ActiveProfile() != "work" || c.DbURL != "sqlite:work.db" || c.CurrentUserName != "bob" {
+		t.Errorf("current_profile work gave %s: %+v", c.ActiveProfile(), c.Profile)
+	}
+	if names := c.ProfileNames(); fmt.Sprint(names) != "[default work]" {
+		t.Errorf("the profiles are %q", names)
+	}
+
+	// GATOR_PROFILE wins over current_profile, and --profile over both.
+	t.Setenv("GATOR_PROFILE", DefaultProfile)
+	c = mustRead(t)
+	if c.DbURL != "sqlite:personal.db" || c.CurrentUserName != "alice" {
+		t.Errorf("GATOR_PROFILE=default gave %+v", c.Profile)
+	}
+	if err := c.UseProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if c.DbURL != "sqlite:work.db" {
+		t.Errorf("UseProfile(work) gave %+v", c.Profile)
+	}
+	if err := c.UseProfile("staging"); err == nil {
+		t.Error("using an unknown profile succeeded")
+	}
+	t.Setenv("GATOR_PROFILE", "staging")
+	if _, err := Read(); err == nil {
+		t.Error("reading with an unknown GATOR_PROFILE succeeded")
+	}
+	t.Setenv("GATOR_PROFILE", "")
+
+	// A session is saved in the profile it was made in.
+	c = mustRead(t)
+	if err := c.SetSession("carol", "carol-token"); err != nil {
+		t.Fatal(err)
+	}
+	c = mustRead(t)
+	if defaults, _ := c.GetProfile(DefaultProfile); defaults.CurrentUserName != "alice" {
+		t.Errorf("a login in work changed the default profile to %+v", defaults)
+	}
+	if c.CurrentUserName != "carol" || c.SessionToken != "carol-token" {
+		t.Errorf("the work profile has %+v", c.Profile)
+	}
+
+	if err := c.AddProfile("work", Profile{DbURL: "memory://"}); err == nil {
+		t.Error("adding work twice succeeded")
+	}
+	if err := c.AddProfile("staging", Profile{DbURL: "mysql://localhost/gator"}); err == nil {
+		t.Error("adding a profile with an unsupported db_url succeeded")
+	}
+	if err := c.SetCurrentProfile("staging"); err == nil {
+		t.Error("switching to an unknown profile succeeded")
+	}
+	if err := c.SetCurrentProfile(DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if c := mustRead(t); c.ActiveProfile() != DefaultProfile || c.CurrentProfile != "" {
+		t.Errorf("after switching back the current profile is %q", c.CurrentProfile)
+	}
+}
+
+func TestWriteIsAtomic(t *testing.T) {
+	home := setHome(t)
+	dir := filepath.Join(home, "xdg", "gator")
+
+	c := mustRead(t)
+	if err := c.SetSession("alice", "alice-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("no_such_key", "value"); err == nil {
+		t.Error("setting an unknown key succeeded")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if name := entry.Name(); name != "config.json" && name != "config.json.lock" {
+			t.Errorf("%s was left in the config directory", name)
+		}
+	}
+	info, err := os.Stat(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("the config file has mode %o, want 600", mode)
+	}
+
+	c = mustRead(t)
+	if c.CurrentUserName != "alice" || c.SessionToken != "alice-token" {
+		t.Errorf("the session was not saved: %+v", c.Profile)
+	}
+}
+
+func TestWriteKeepsConcurrentChanges(t *testing.T) {
+	setHome(t)
+
+	// Both processes read the config before either of them writes it.
+	login, profile := mustRead(t), mustRead(t)
+	if err := login.SetSession("alice", "alice-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.AddProfile("work", Profile{DbURL: "memory://"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.SetCurrentProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := mustRead(t)
+	defaults, _ := c.GetProfile(DefaultProfile)
+	if defaults.CurrentUserName != "alice" || defaults.SessionToken != "alice-token" {
+		t.Errorf("the login was lost: %+v", defaults)
+	}
+	if c.ActiveProfile() != "work" {
+		t.Errorf("the current profile is %s, want work", c.ActiveProfile())
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := range n {
+		c := mustRead(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.Set(fmt.Sprintf("templates.t%d", i), "{{.Title}}")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c := mustRead(t); len(c.Templates) != n {
+		t.Errorf("%d templates after %d concurrent changes", len(c.Templates), n)
+	}
+}
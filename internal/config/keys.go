@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const templateKeyPrefix = "templates."
+
+// Keys are the settings "gator config get/set" knows. Templates are set
+// with templates.<name> and command timeouts with timeouts.<command>.
+var Keys = []string{
+	"db_url",
+	"current_user_name",
+	"session_token",
+	"current_profile",
+	"retention.max_age",
+	"retention.max_posts",
+	"retention.keep_unread",
+	"retention.keep_starred",
+	"pool.max_open_conns",
+	"pool.max_idle_conns",
+	"pool.conn_max_lifetime",
+	"pool.conn_max_idle_time",
+	"timeouts.default",
+}
+
+// Get returns a setting as gator uses it, including the overrides from the
+// environment and flags.
+func (c *Config) Get(key string) (string, error) {
+	switch key {
+	case "db_url":
+		return c.DbURL, nil
+	case "current_user_name":
+		return c.CurrentUserName, nil
+	case "session_token":
+		return c.SessionToken, nil
+	case "current_profile":
+		return c.ActiveProfile(), nil
+	}
+
+	if name, ok := strings.CutPrefix(key, retentionKeyPrefix); ok {
+		return c.Retention.get(name)
+	}
+	if name, ok := strings.CutPrefix(key, poolKeyPrefix); ok {
+		return c.Pool.get(name)
+	}
+	if name, ok := strings.CutPrefix(key, timeoutKeyPrefix); ok {
+		return c.Timeouts[name], nil
+	}
+	if name, ok := strings.CutPrefix(key, templateKeyPrefix); ok {
+		text, ok := c.Templates[name]
+		if !ok {
+			return "", fmt.Errorf("unknown template %q", name)
+		}
+		return text, nil
+	}
+	return "", unknownKeyError(key)
+}
+
+// Set changes a setting of the profile in use and saves the config. An
+// empty value removes a template or timeout, or restores a retention or
+// pool default.
+func (c *Config) Set(key, value string) error {
+	if key == "current_profile" {
+		return c.SetCurrentProfile(value)
+	}
+	return c.update(func(f *Config) error {
+		return f.set(key, value)
+	})
+}
+
+func (c *Config) set(key, value string) error {
+	p := c.stored()
+	switch key {
+	case "db_url":
+		if err := validateDbURL(value); err != nil {
+			return err
+		}
+		p.DbURL = value
+	case "current_user_name":
+		p.CurrentUserName = value
+	case "session_token":
+		p.SessionToken = value
+	default:
+		if name, ok := strings.CutPrefix(key, retentionKeyPrefix); ok {
+			return c.Retention.set(name, value)
+		}
+		if name, ok := strings.CutPrefix(key, poolKeyPrefix); ok {
+			return c.Pool.set(name, value)
+		}
+		if name, ok := strings.CutPrefix(key, timeoutKeyPrefix); ok && name != "" {
+			if value == "" {
+				delete(c.Timeouts, name)
+				return nil
+			}
+			if _, err := ParseTimeout(value); err != nil {
+				return err
+			}
+			if c.Timeouts == nil {
+				c.Timeouts = map[string]string{}
+			}
+			c.Timeouts[name] = value
+			return nil
+		}
+		name, ok := strings.CutPrefix(key, templateKeyPrefix)
+		if !ok || name == "" {
+			return unknownKeyError(key)
+		}
+		if c.Templates == nil {
+			c.Templates = map[string]string{}
+		}
+		if value == "" {
+			delete(c.Templates, name)
+		} else {
+			c.Templates[name] = value
+		}
+		return nil
+	}
+
+	c.store(p)
+	return nil
+}
+
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key %q, expected one of: %s, %s<name>, %s<command>", key, strings.Join(Keys, ", "), templateKeyPrefix, timeoutKeyPrefix)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// lockFile is a no-op where flock is not available. Writes are still
+// atomic, only concurrent changes may be lost.
+func lockFile(configPath string, exclusive bool) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const poolKeyPrefix = "pool."
+
+// Pool sizes the database connection pool. Unset settings keep the
+// defaults of database/sql: unlimited open connections, 2 idle ones and
+// connections that are reused forever.
+type Pool struct {
+	MaxOpenConns    int    `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int    `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime string `json:"conn_max_lifetime,omitempty"`
+	ConnMaxIdleTime string `json:"conn_max_idle_time,omitempty"`
+}
+
+func (p Pool) validate() error {
+	if p.MaxOpenConns < 0 || p.MaxIdleConns < 0 {
+		return fmt.Errorf("pool: connection counts can not be negative")
+	}
+	for _, value := range []string{p.ConnMaxLifetime, p.ConnMaxIdleTime} {
+		if value == "" {
+			continue
+		}
+		if _, err := parsePoolDuration(value); err != nil {
+			return fmt.Errorf("pool: %v", err)
+		}
+	}
+	return nil
+}
+
+func parsePoolDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 30m", value)
+	}
+	return d, nil
+}
+
+// ConnMaxLifetimeDuration and ConnMaxIdleTimeDuration return the
+// durations, or 0 when unset.
+func (p Pool) ConnMaxLifetimeDuration() time.Duration {
+	d, _ := parsePoolDuration(p.ConnMaxLifetime)
+	return d
+}
+
+func (p Pool) ConnMaxIdleTimeDuration() time.Duration {
+	d, _ := parsePoolDuration(p.ConnMaxIdleTime)
+	return d
+}
+
+func (p Pool) get(key string) (string, error) {
+	switch key {
+	case "max_open_conns":
+		return strconv.Itoa(p.MaxOpenConns), nil
+	case "max_idle_conns":
+		return strconv.Itoa(p.MaxIdleConns), nil
+	case "conn_max_lifetime":
+		return p.ConnMaxLifetime, nil
+	case "conn_max_idle_time":
+		return p.ConnMaxIdleTime, nil
+	}
+	return "", unknownKeyError(poolKeyPrefix + key)
+}
+
+// set changes a pool setting. An empty value restores the default.
+func (p *Pool) set(key, value string) error {
+	switch key {
+	case "max_open_conns", "max_idle_conns":
+		n := 0
+		if value != "" {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s %q, expected a number", key, value)
+			}
+		}
+		if key == "max_open_conns" {
+			p.MaxOpenConns = n
+		} else {
+			p.MaxIdleConns = n
+		}
+	case "conn_max_lifetime", "conn_max_idle_time":
+		if value != "" {
+			if _, err := parsePoolDuration(value); err != nil {
+				return err
+			}
+		}
+		if key == "conn_max_lifetime" {
+			p.ConnMaxLifetime = value
+		} else {
+			p.ConnMaxIdleTime = value
+		}
+	default:
+		return unknownKeyError(poolKeyPrefix + key)
+	}
+	return nil
+}
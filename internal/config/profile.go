@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+const DefaultProfile = "default"
+
+// Profile is a database together with the user logged in to it.
+type Profile struct {
+	DbURL           string `json:"db_url"`
+	CurrentUserName string `json:"current_user_name"`
+	SessionToken    string `json:"session_token,omitempty"`
+}
+
+// UseProfile switches the config to the named profile, without saving it
+// as the current one, and checks its db_url. An empty name selects the
+// default profile.
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if _, ok := c.GetProfile(name); !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	c.active = name
+	c.apply()
+	if err := validateDbURL(c.DbURL); err != nil {
+		return fmt.Errorf("profile %s: %v", name, err)
+	}
+	return nil
+}
+
+// ActiveProfile returns the name of the profile in use.
+func (c *Config) ActiveProfile() string {
+	if c.active == "" {
+		return DefaultProfile
+	}
+	return c.active
+}
+
+// GetProfile returns a profile as it is stored in the file.
+func (c *Config) GetProfile(name string) (Profile, bool) {
+	if name == DefaultProfile {
+		return c.defaults, true
+	}
+	p, ok := c.Profiles[name]
+	return p, ok
+}
+
+// ProfileNames returns the sorted names of all profiles, including the
+// default one.
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+func (c *Config) AddProfile(name string, p Profile) error {
+	if name == "" {
+		return fmt.Errorf("profile name can not be empty")
+	}
+	if err := validateDbURL(p.DbURL); err != nil {
+		return err
+	}
+
+	return c.update(func(f *Config) error {
+		if _, ok := f.GetProfile(name); ok {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+		if f.Profiles == nil {
+			f.Profiles = map[string]Profile{}
+		}
+		f.Profiles[name] = p
+		return nil
+	})
+}
+
+// SetCurrentProfile saves the profile used when neither --profile nor
+// GATOR_PROFILE is given.
+func (c *Config) SetCurrentProfile(name string) error {
+	return c.update(func(f *Config) error {
+		p, ok := f.GetProfile(name)
+		if !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		if err := validateDbURL(p.DbURL); err != nil {
+			return fmt.Errorf("profile %s: %v", name, err)
+		}
+
+		f.CurrentProfile = name
+		if name == DefaultProfile {
+			f.CurrentProfile = ""
+		}
+		return nil
+	})
+}
+
+func (c *Config) stored() Profile {
+	p, _ := c.GetProfile(c.ActiveProfile())
+	return p
+}
+
+func (c *Config) store(p Profile) {
+	if c.ActiveProfile() == DefaultProfile {
+		c.defaults = p
+		return
+	}
+	c.Profiles[c.active] = p
+}
+
+// file returns the config as it is stored, with the default profile at
+// the top level.
+func (c *Config) file() *Config {
+	out := *c
+	out.Profile = c.defaults
+	return &out
+}
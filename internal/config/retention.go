@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const retentionKeyPrefix = "retention."
+
+// Retention is the post retention policy for all feeds. Feeds can override
+// it with "gator feed retention". Nothing is pruned until MaxAge or
+// MaxPosts is set.
+type Retention struct {
+	MaxAge      string `json:"max_age,omitempty"`
+	MaxPosts    int    `json:"max_posts,omitempty"`
+	KeepUnread  bool   `json:"keep_unread,omitempty"`
+	KeepStarred *bool  `json:"keep_starred,omitempty"`
+}
+
+// KeepsStarred reports whether starred posts are kept, which they are
+// unless keep_starred is turned off.
+func (r Retention) KeepsStarred() bool {
+	return r.KeepStarred == nil || *r.KeepStarred
+}
+
+// ParseAge parses a max age: a Go duration such as 72h, or a number of
+// days such as 30d. 0 means no limit.
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid max age %q, expected e.g. 30d or 72h", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid max age %q, expected e.g. 30d or 72h", s)
+	}
+	return age, nil
+}
+
+func (r Retention) validate() error {
+	if r.MaxAge != "" {
+		if _, err := ParseAge(r.MaxAge); err != nil {
+			return fmt.Errorf("retention: %v", err)
+		}
+	}
+	if r.MaxPosts < 0 {
+		return fmt.Errorf("retention: max_posts can not be negative")
+	}
+	return nil
+}
+
+func (r Retention) get(key string) (string, error) {
+	switch key {
+	case "max_age":
+		return r.MaxAge, nil
+	case "max_posts":
+		return strconv.Itoa(r.MaxPosts), nil
+	case "keep_unread":
+		return strconv.FormatBool(r.KeepUnread), nil
+	case "keep_starred":
+		return strconv.FormatBool(r.KeepsStarred()), nil
+	}
+	return "", unknownKeyError(retentionKeyPrefix + key)
+}
+
+// set changes a retention setting. An empty value restores the default.
+func (r *Retention) set(key, value string) error {
+	switch key {
+	case "max_age":
+		if value != "" {
+			if _, err := ParseAge(value); err != nil {
+				return err
+			}
+		}
+		r.MaxAge = value
+	case "max_posts":
+		n := 0
+		if value != "" {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid max_posts %q, expected a number", value)
+			}
+		}
+		r.MaxPosts = n
+	case "keep_unread", "keep_starred":
+		var keep *bool
+		if value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q, expected true or false", key, value)
+			}
+			keep = &b
+		}
+		if key == "keep_unread" {
+			r.KeepUnread = keep != nil && *keep
+		} else {
+			r.KeepStarred = keep
+		}
+	default:
+		return unknownKeyError(retentionKeyPrefix + key)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const timeoutKeyPrefix = "timeouts."
+
+// DefaultTimeout is the timeouts entry for commands without their own.
+const DefaultTimeout = "default"
+
+// ParseTimeout parses a command timeout such as 30s or 5m. 0 means no
+// timeout.
+func ParseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid timeout %q, expected e.g. 30s or 5m", s)
+	}
+	return d, nil
+}
+
+// OverrideTimeout sets the timeout of every command for this run only, for
+// the --timeout flag.
+func (c *Config) OverrideTimeout(value string) error {
+	timeout, err := ParseTimeout(value)
+	if err != nil {
+		return err
+	}
+	c.flagTimeout = &timeout
+	return nil
+}
+
+// Timeout returns the timeout of a command, or of DefaultTimeout: the one
+// of --timeout if given, else GATOR_TIMEOUT, else the one in the file. ok
+// is false when none is set.
+func (c *Config) Timeout(name string) (timeout time.Duration, ok bool) {
+	if c.flagTimeout != nil {
+		return *c.flagTimeout, true
+	}
+	if c.envTimeout != nil {
+		return *c.envTimeout, true
+	}
+	value, ok := c.Timeouts[name]
+	if !ok {
+		return 0, false
+	}
+	// The timeouts were checked when the config was read.
+	timeout, _ = ParseTimeout(value)
+	return timeout, true
+}
+
+// FlagTimeout returns the timeout given with --timeout. ok is false
+// without one.
+func (c *Config) FlagTimeout() (timeout time.Duration, ok bool) {
+	if c.flagTimeout == nil {
+		return 0, false
+	}
+	return *c.flagTimeout, true
+}
+
+func validateTimeouts(timeouts map[string]string) error {
+	for name, value := range timeouts {
+		if name == "" {
+			return fmt.Errorf("timeouts: command names can not be empty")
+		}
+		if _, err := ParseTimeout(value); err != nil {
+			return fmt.Errorf("timeouts.%s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/babanini95/gatorcli/internal/migrate"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The benchmarks time the queries behind browse, tui, the HTTP API and agg
+// on a seeded database:
+//
+//	go test ./internal/database -run '^$' -bench .
+//	GATOR_BENCH_POSTS=1000000 go test ./internal/database -run '^$' -bench . -timeout 30m
+//
+// GATOR_BENCH_DB_URL names an empty database to seed instead of a new
+// SQLite file, GATOR_BENCH_POSTS, GATOR_BENCH_FEEDS and
+// GATOR_BENCH_FOLLOWS change its size.
+const (
+	// postsPerTx is how many posts are seeded per transaction.
+	postsPerTx = 10000
+	// maxBrowse is the slowest acceptable browse query.
+	maxBrowse = 100 * time.Millisecond
+)
+
+// bench is the seeded database, nil unless benchmarks run.
+var bench *benchData
+
+type benchData struct {
+	db     database.Store
+	userID uuid.NullUUID
+}
+
+type benchOptions struct {
+	dbURL   string
+	posts   int
+	feeds   int
+	follows int
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if f := flag.Lookup("test.bench"); f == nil || f.Value.String() == "" {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "gator-bench-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	bench, err = seedBench(context.Background(), dir)
+	code := 1
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can not seed the benchmark database: %v\n", err)
+	} else {
+		code = m.Run()
+	}
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func seedBench(ctx context.Context, dir string) (*benchData, error) {
+	opts := benchOptions{
+		dbURL:   os.Getenv("GATOR_BENCH_DB_URL"),
+		posts:   100000,
+		feeds:   1000,
+		follows: 100,
+	}
+	for name, value := range map[string]*int{
+		"GATOR_BENCH_POSTS":   &opts.posts,
+		"GATOR_BENCH_FEEDS":   &opts.feeds,
+		"GATOR_BENCH_FOLLOWS": &opts.follows,
+	} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			*value = n
+		}
+	}
+	if opts.feeds < 1 || opts.follows < 1 || opts.follows > opts.feeds {
+		return nil, fmt.Errorf("need at least one feed, and no more follows than feeds")
+	}
+	if opts.dbURL == "" {
+		opts.dbURL = "sqlite:" + filepath.Join(dir, "gator.db")
+	}
+
+	db, err := openBench(ctx, opts.dbURL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.CountRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rows.Users+rows.Feeds+rows.Follows+rows.Posts > 0 {
+		return nil, fmt.Errorf("the database is not empty, the benchmarks need an empty one to seed")
+	}
+
+	start := time.Now()
+	user, err := seed(ctx, db, opts)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Seeded %d feeds and %d posts in %s\n", opts.feeds, opts.posts, time.Since(start).Round(time.Millisecond))
+	return &benchData{db: db, userID: uuid.NullUUID{UUID: user.ID, Valid: true}}, nil
+}
+
+// openBench connects to the database and migrates it to the latest schema.
+func openBench(ctx context.Context, dbURL string) (database.Store, error) {
+	driver, dsn, err := database.Driver(dbURL)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "memory" {
+		return database.NewMemoryStore(), nil
+	}
+
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.New(conn, driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Up(ctx); err != nil {
+		return nil, err
+	}
+	return database.New(conn), nil
+}
+
+// seed adds the benchmark user following opts.follows of the feeds, and
+// the posts spread over all feeds with publication dates over the last
+// two years.
+func seed(ctx context.Context, db database.Store, opts benchOptions) (database.User, error) {
+	now := time.Now().UTC()
+	user, err := db.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		Name:      "bench",
+	})
+	if err != nil {
+		return user, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	feedIDs := make([]uuid.UUID, opts.feeds)
+	for i := range feedIDs {
+		feed, err := db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt: sql.NullTime{Time: now, Valid: true},
+			Name:      sql.NullString{String: fmt.Sprintf("Feed %d", i), Valid: true},
+			Url:       sql.NullString{String: fmt.Sprintf("https://example.com/feed/%d.xml", i), Valid: true},
+			UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		})
+		if err != nil {
+			return user, fmt.Errorf("failed to create feed: %v", err)
+		}
+		feedIDs[i] = feed.ID
+	}
+	for _, feedID := range feedIDs[:opts.follows] {
+		_, err := db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt: sql.NullTime{Time: now, Valid: true},
+			UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+			FeedID:    uuid.NullUUID{UUID: feedID, Valid: true},
+		})
+		if err != nil {
+			return user, fmt.Errorf("failed to follow feed: %v", err)
+		}
+	}
+
+	const twoYears = 2 * 365 * 24 * time.Hour
+	for first := 0; first < opts.posts; first += postsPerTx {
+		posts := make([]database.CreatePostParams, 0, postsPerTx)
+		for i := first; i < min(first+postsPerTx, opts.posts); i++ {
+			published := now.Add(-rand.N(twoYears))
+			posts = append(posts, database.CreatePostParams{
+				ID:          uuid.New(),
+				CreatedAt:   sql.NullTime{Time: now, Valid: true},
+				UpdatedAt:   sql.NullTime{Time: now, Valid: true},
+				Title:       sql.NullString{String: fmt.Sprintf("Post %d", i), Valid: true},
+				Url:         sql.NullString{String: fmt.Sprintf("https://example.com/post/%d", i), Valid: true},
+				Description: sql.NullString{String: "A post seeded by the benchmarks.", Valid: true},
+				PublishedAt: sql.NullTime{Time: published, Valid: true},
+				FeedID:      uuid.NullUUID{UUID: feedIDs[i%len(feedIDs)], Valid: true},
+			})
+		}
+		err := db.InTx(ctx, func(tx database.Store) error {
+			_, err := tx.CreatePosts(ctx, posts)
+			return err
+		})
+		if err != nil {
+			return user, fmt.Errorf("failed to create posts: %v", err)
+		}
+	}
+	return user, nil
+}
+
+// runBench runs query b.N times on the seeded database.
+func runBench(b *testing.B, query func(ctx context.Context, db database.Store, userID uuid.NullUUID) error) {
+	if bench == nil {
+		b.Skip("the benchmark database is not seeded")
+	}
+	ctx := context.Background()
+	for b.Loop() {
+		if err := query(ctx, bench.db, bench.userID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBrowse(b *testing.B) {
+	runBench(b, func(ctx context.Context, db database.Store, userID uuid.NullUUID) error {
+		_, err := db.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: userID, Limit: 10})
+		return err
+	})
+	if perQuery := b.Elapsed() / time.Duration(b.N); perQuery > maxBrowse {
+		b.Errorf("browse took %s, more than %s", perQuery.Round(time.Microsecond), maxBrowse)
+	}
+}
+
+func BenchmarkTUI(b *testing.B) {
+	runBench(b, func(ctx context.Context, db database.Store, userID uuid.NullUUID) error {
+		_, err := db.GetPostsWithStateForUser(ctx, database.GetPostsWithStateForUserParams{UserID: userID, Limit: 50})
+		return err
+	})
+}
+
+func BenchmarkAPIPosts(b *testing.B) {
+	runBench(b, func(ctx context.Context, db database.Store, userID uuid.NullUUID) error {
+		_, err := db.ListPostsForUser(ctx, database.ListPostsForUserParams{UserID: userID, PageLimit: 50})
+		return err
+	})
+}
+
+func BenchmarkAggNextFeed(b *testing.B) {
+	runBench(b, func(ctx context.Context, db database.Store, userID uuid.NullUUID) error {
+		_, err := db.GetNextFeedToFetch(ctx, userID)
+		return err
+	})
+}
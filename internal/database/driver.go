@@ -0,0 +1,32 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Driver returns the database/sql driver and data source name for a
+// db_url. SQLite urls name a file, e.g. sqlite:gator.db or
+// sqlite:///var/lib/gator.db. memory:// stands for the in-memory store.
+func Driver(dbURL string) (driver, dsn string, err error) {
+	scheme, rest, _ := strings.Cut(dbURL, ":")
+	switch scheme {
+	case "postgres", "postgresql":
+		return "postgres", dbURL, nil
+	case "memory":
+		return "memory", "", nil
+	case "sqlite", "sqlite3":
+		path := strings.TrimPrefix(rest, "//")
+		if path == "" {
+			return "", "", fmt.Errorf("db_url %s has no file name", dbURL)
+		}
+		// SQLite only enforces the ON DELETE CASCADE foreign keys when
+		// asked to, and waits for locks held by other gator processes.
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		return "sqlite3", path + sep + "_foreign_keys=on&_busy_timeout=5000", nil
+	}
+	return "", "", fmt.Errorf("unsupported db_url scheme %q", scheme)
+}
@@ -15,7 +15,7 @@ import (
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
 `
 
 type CreateFeedParams struct {
@@ -45,22 +45,25 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastFetchError,
 	)
 	return i, err
 }
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-WITH inserted_feed_follow AS (
-    INSERT INTO feeds_follow (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
-)
-SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
-    feeds.name AS feed_name,
-    users.name AS user_name
-FROM inserted_feed_follow
-    INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id
-    INNER JOIN users ON inserted_feed_follow.user_id = users.id
+INSERT INTO feeds_follow (id, created_at, updated_at, user_id, feed_id, folder)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, user_id, feed_id, folder,
+    (
+        SELECT feeds.name
+        FROM feeds
+        WHERE feeds.id = feeds_follow.feed_id
+    ) AS feed_name,
+    (
+        SELECT users.name
+        FROM users
+        WHERE users.id = feeds_follow.user_id
+    ) AS user_name
 `
 
 type CreateFeedFollowParams struct {
@@ -69,6 +72,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.NullUUID
 	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -77,6 +81,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.NullUUID
 	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 	FeedName  sql.NullString
 	UserName  string
 }
@@ -88,6 +93,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Folder,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -96,12 +102,32 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
+const deleteAllFeeds = `-- name: DeleteAllFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteAllFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllFeeds)
+	return err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const deleteFeedFollowsByUrl = `-- name: DeleteFeedFollowsByUrl :exec
 DELETE FROM feeds_follow
 WHERE feeds_follow.user_id = $1
@@ -123,7 +149,7 @@ func (q *Queries) DeleteFeedFollowsByUrl(ctx context.Context, arg DeleteFeedFoll
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
 FROM feeds
 WHERE url = $1
 `
@@ -139,14 +165,16 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url sql.NullString) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastFetchError,
 	)
 	return i, err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feeds_follow.id, feeds_follow.created_at, feeds_follow.updated_at, feeds_follow.user_id, feeds_follow.feed_id,
+SELECT feeds_follow.id, feeds_follow.created_at, feeds_follow.updated_at, feeds_follow.user_id, feeds_follow.feed_id, feeds_follow.folder,
     users.name AS user_name,
-    feeds.name AS feed_name
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
 FROM feeds_follow
     INNER JOIN users ON feeds_follow.user_id = users.id
     INNER JOIN feeds ON feeds_follow.feed_id = feeds.id
@@ -159,8 +187,10 @@ type GetFeedFollowsForUserRow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.NullUUID
 	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 	UserName  string
 	FeedName  sql.NullString
+	FeedUrl   sql.NullString
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.NullUUID) ([]GetFeedFollowsForUserRow, error) {
@@ -178,8 +208,10 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.NullUUI
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Folder,
 			&i.UserName,
 			&i.FeedName,
+			&i.FeedUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -194,8 +226,90 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.NullUUI
 	return items, nil
 }
 
+const getFeedStats = `-- name: GetFeedStats :one
+SELECT f.id, f.created_at, f.updated_at, f.name, f.url, f.user_id, f.last_fetched_at, f.last_fetch_error,
+    u.name AS owner_name,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+        WHERE ff.feed_id = f.id
+    ) AS followers,
+    (
+        SELECT COUNT(*)
+        FROM posts p
+        WHERE p.feed_id = f.id
+    ) AS posts
+FROM feeds f
+    LEFT JOIN users u ON f.user_id = u.id
+WHERE f.url = $1
+`
+
+type GetFeedStatsRow struct {
+	ID             uuid.UUID
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Name           sql.NullString
+	Url            sql.NullString
+	UserID         uuid.NullUUID
+	LastFetchedAt  sql.NullTime
+	LastFetchError sql.NullString
+	OwnerName      sql.NullString
+	Followers      int64
+	Posts          int64
+}
+
+func (q *Queries) GetFeedStats(ctx context.Context, url sql.NullString) (GetFeedStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedStats, url)
+	var i GetFeedStatsRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.OwnerName,
+		&i.Followers,
+		&i.Posts,
+	)
+	return i, err
+}
+
+const getFoldersForUser = `-- name: GetFoldersForUser :many
+SELECT DISTINCT folder
+FROM feeds_follow
+WHERE user_id = $1
+    AND folder IS NOT NULL
+ORDER BY folder
+`
+
+func (q *Queries) GetFoldersForUser(ctx context.Context, userID uuid.NullUUID) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, getFoldersForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var folder sql.NullString
+		if err := rows.Scan(&folder); err != nil {
+			return nil, err
+		}
+		items = append(items, folder)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
 FROM feeds f
 WHERE f.id IN (
         SELECT ff.feed_id
@@ -217,6 +331,34 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context, userID uuid.NullUUID)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastFetchError,
+	)
+	return i, err
+}
+
+const getNextFollowedFeedToFetch = `-- name: GetNextFollowedFeedToFetch :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST
+LIMIT 1
+`
+
+func (q *Queries) GetNextFollowedFeedToFetch(ctx context.Context) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFollowedFeedToFetch)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
 	)
 	return i, err
 }
@@ -274,3 +416,183 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.ID)
 	return err
 }
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $1,
+    updated_at = $2
+WHERE id = $3
+`
+
+type RenameFeedParams struct {
+	Name      sql.NullString
+	UpdatedAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.Name, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const resetFeedsFetched = `-- name: ResetFeedsFetched :exec
+UPDATE feeds
+SET last_fetched_at = NULL
+`
+
+func (q *Queries) ResetFeedsFetched(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetFeedsFetched)
+	return err
+}
+
+const setFeedFetchError = `-- name: SetFeedFetchError :exec
+UPDATE feeds
+SET last_fetch_error = $1
+WHERE id = $2
+`
+
+type SetFeedFetchErrorParams struct {
+	LastFetchError sql.NullString
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetFeedFetchError(ctx context.Context, arg SetFeedFetchErrorParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchError, arg.LastFetchError, arg.ID)
+	return err
+}
+
+const listFeedsToFetch = `-- name: ListFeedsToFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+        WHERE ff.user_id = $1
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST
+`
+
+func (q *Queries) ListFeedsToFetch(ctx context.Context, userID uuid.NullUUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedsToFetch, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastFetchError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowedFeedsToFetch = `-- name: ListFollowedFeedsToFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST
+`
+
+func (q *Queries) ListFollowedFeedsToFetch(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowedFeedsToFetch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastFetchError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :execrows
+UPDATE feeds_follow
+SET folder = $1,
+    updated_at = $2
+WHERE feeds_follow.user_id = $3
+    AND feeds_follow.feed_id = (
+        SELECT id
+        FROM feeds
+        WHERE url = $4
+    )
+`
+
+type SetFeedFollowFolderParams struct {
+	Folder    sql.NullString
+	UpdatedAt sql.NullTime
+	UserID    uuid.NullUUID
+	Url       sql.NullString
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowFolder,
+		arg.Folder,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.Url,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $1,
+    last_fetch_error = NULL,
+    updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedUrlParams struct {
+	Url       sql.NullString
+	UpdatedAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.Url, arg.UpdatedAt, arg.ID)
+	return err
+}
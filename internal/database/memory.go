@@ -0,0 +1,1530 @@
+package database
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is a Store that keeps everything in memory, with the same
+// constraints, cascades and ordering as the SQL schema. Nothing outlives the
+// process, so it is meant for tests and trying gator out.
+type MemoryStore struct {
+	mu sync.Mutex
+	memoryTables
+}
+
+type memoryTables struct {
+	users     []User
+	sessions  []Session
+	feeds     []Feed
+	retention []FeedRetention
+	follows   []FeedsFollow
+	posts     []Post
+	states    []PostsState
+	seq       int64
+}
+
+func (t memoryTables) clone() memoryTables {
+	return memoryTables{
+		users:     slices.Clone(t.users),
+		sessions:  slices.Clone(t.sessions),
+		feeds:     slices.Clone(t.feeds),
+		retention: slices.Clone(t.retention),
+		follows:   slices.Clone(t.follows),
+		posts:     slices.Clone(t.posts),
+		states:    slices.Clone(t.states),
+		seq:       t.seq,
+	}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// The errors read like the SQLite ones, so callers looking for duplicates
+// handle every store the same way.
+var errForeignKey = errors.New("FOREIGN KEY constraint failed")
+
+func errUnique(column string) error {
+	return fmt.Errorf("UNIQUE constraint failed: %s", column)
+}
+
+// userPost is a post of a feed followed by a user, joined with the feed, the
+// follow and the user's read and starred marks.
+type userPost struct {
+	Post
+	feed   Feed
+	follow FeedsFollow
+	state  PostsState
+}
+
+func (m *MemoryStore) user(id uuid.UUID) (User, bool) {
+	i := slices.IndexFunc(m.users, func(u User) bool { return u.ID == id })
+	if i < 0 {
+		return User{}, false
+	}
+	return m.users[i], true
+}
+
+func (m *MemoryStore) feed(id uuid.NullUUID) (Feed, bool) {
+	i := slices.IndexFunc(m.feeds, func(f Feed) bool { return id.Valid && f.ID == id.UUID })
+	if i < 0 {
+		return Feed{}, false
+	}
+	return m.feeds[i], true
+}
+
+func (m *MemoryStore) feedByURL(url sql.NullString) (Feed, bool) {
+	i := slices.IndexFunc(m.feeds, func(f Feed) bool { return url.Valid && f.Url == url })
+	if i < 0 {
+		return Feed{}, false
+	}
+	return m.feeds[i], true
+}
+
+func (m *MemoryStore) state(userID, postID uuid.UUID) PostsState {
+	i := slices.IndexFunc(m.states, func(ps PostsState) bool {
+		return ps.UserID == userID && ps.PostID == postID
+	})
+	if i < 0 {
+		return PostsState{}
+	}
+	return m.states[i]
+}
+
+// userPosts returns the posts of the feeds the user follows.
+func (m *MemoryStore) userPosts(userID uuid.NullUUID) []userPost {
+	posts := []userPost{}
+	for _, ff := range m.follows {
+		if !sameID(ff.UserID, userID) {
+			continue
+		}
+		feed, ok := m.feed(ff.FeedID)
+		if !ok {
+			continue
+		}
+		for _, p := range m.posts {
+			if sameID(p.FeedID, ff.FeedID) {
+				posts = append(posts, userPost{
+					Post:   p,
+					feed:   feed,
+					follow: ff,
+					state:  m.state(userID.UUID, p.ID),
+				})
+			}
+		}
+	}
+	return posts
+}
+
+// deleteUsers deletes the matching users with their sessions, follows,
+// marks and the feeds they added.
+func (m *MemoryStore) deleteUsers(match func(User) bool) {
+	ids := map[uuid.UUID]bool{}
+	for _, u := range m.users {
+		if match(u) {
+			ids[u.ID] = true
+		}
+	}
+	m.sessions = slices.DeleteFunc(m.sessions, func(s Session) bool { return ids[s.UserID] })
+	m.follows = slices.DeleteFunc(m.follows, func(ff FeedsFollow) bool { return ff.UserID.Valid && ids[ff.UserID.UUID] })
+	m.states = slices.DeleteFunc(m.states, func(ps PostsState) bool { return ids[ps.UserID] })
+	m.deleteFeeds(func(f Feed) bool { return f.UserID.Valid && ids[f.UserID.UUID] })
+	m.users = slices.DeleteFunc(m.users, func(u User) bool { return ids[u.ID] })
+}
+
+// deleteFeeds deletes the matching feeds with their retention, follows and
+// posts.
+func (m *MemoryStore) deleteFeeds(match func(Feed) bool) {
+	ids := map[uuid.UUID]bool{}
+	for _, f := range m.feeds {
+		if match(f) {
+			ids[f.ID] = true
+		}
+	}
+	m.retention = slices.DeleteFunc(m.retention, func(r FeedRetention) bool { return ids[r.FeedID] })
+	m.follows = slices.DeleteFunc(m.follows, func(ff FeedsFollow) bool { return ff.FeedID.Valid && ids[ff.FeedID.UUID] })
+	m.deletePosts(func(p Post) bool { return p.FeedID.Valid && ids[p.FeedID.UUID] })
+	m.feeds = slices.DeleteFunc(m.feeds, func(f Feed) bool { return ids[f.ID] })
+}
+
+// deletePosts deletes the matching posts with their marks.
+func (m *MemoryStore) deletePosts(match func(Post) bool) {
+	ids := map[uuid.UUID]bool{}
+	for _, p := range m.posts {
+		if match(p) {
+			ids[p.ID] = true
+		}
+	}
+	m.states = slices.DeleteFunc(m.states, func(ps PostsState) bool { return ids[ps.PostID] })
+	m.posts = slices.DeleteFunc(m.posts, func(p Post) bool { return ids[p.ID] })
+}
+
+func (m *MemoryStore) CountAdmins(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, u := range m.users {
+		if u.Role == "admin" {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) CountPostsForUser(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.userPosts(userID))), nil
+}
+
+func (m *MemoryStore) CountPrunablePosts(ctx context.Context, arg CountPrunablePostsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.prunable(arg))), nil
+}
+
+func (m *MemoryStore) CountRows(ctx context.Context) (CountRowsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return CountRowsRow{
+		Users:   int64(len(m.users)),
+		Feeds:   int64(len(m.feeds)),
+		Follows: int64(len(m.follows)),
+		Posts:   int64(len(m.posts)),
+	}, nil
+}
+
+func (m *MemoryStore) CountRowsForUser(ctx context.Context, userID uuid.NullUUID) (CountRowsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var i CountRowsForUserRow
+	owned := map[uuid.UUID]bool{}
+	for _, f := range m.feeds {
+		if sameID(f.UserID, userID) {
+			owned[f.ID] = true
+			i.Feeds++
+		}
+	}
+	for _, ff := range m.follows {
+		if sameID(ff.UserID, userID) {
+			i.Follows++
+		} else if ff.FeedID.Valid && owned[ff.FeedID.UUID] && ff.UserID.Valid {
+			i.OtherFollows++
+		}
+	}
+	for _, p := range m.posts {
+		if p.FeedID.Valid && owned[p.FeedID.UUID] {
+			i.Posts++
+		}
+	}
+	return i, nil
+}
+
+func (m *MemoryStore) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.feeds, func(f Feed) bool { return f.ID == arg.ID }) {
+		return Feed{}, errUnique("feeds.id")
+	}
+	if _, ok := m.feedByURL(arg.Url); ok {
+		return Feed{}, errUnique("feeds.url")
+	}
+	if _, ok := m.user(arg.UserID.UUID); arg.UserID.Valid && !ok {
+		return Feed{}, errForeignKey
+	}
+	feed := Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+	}
+	m.feeds = append(m.feeds, feed)
+	return feed, nil
+}
+
+func (m *MemoryStore) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.follows, func(ff FeedsFollow) bool {
+		return ff.ID == arg.ID || sameID(ff.UserID, arg.UserID) && sameID(ff.FeedID, arg.FeedID)
+	}) {
+		return CreateFeedFollowRow{}, errUnique("feeds_follow.user_id, feeds_follow.feed_id")
+	}
+	user, userOK := m.user(arg.UserID.UUID)
+	feed, feedOK := m.feed(arg.FeedID)
+	if arg.UserID.Valid && !userOK || arg.FeedID.Valid && !feedOK {
+		return CreateFeedFollowRow{}, errForeignKey
+	}
+	m.follows = append(m.follows, FeedsFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		Folder:    arg.Folder,
+	})
+	return CreateFeedFollowRow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		Folder:    arg.Folder,
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (m *MemoryStore) CreatePost(ctx context.Context, arg CreatePostParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.posts, func(p Post) bool { return p.ID == arg.ID }) {
+		return errUnique("posts.id")
+	}
+	if slices.ContainsFunc(m.posts, func(p Post) bool { return arg.Url.Valid && p.Url == arg.Url }) {
+		return errUnique("posts.url")
+	}
+	if _, ok := m.feed(arg.FeedID); arg.FeedID.Valid && !ok {
+		return errForeignKey
+	}
+	m.seq++
+	m.posts = append(m.posts, Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Seq:         m.seq,
+	})
+	return nil
+}
+
+func (m *MemoryStore) CreatePosts(ctx context.Context, posts []CreatePostParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var inserted int64
+	for _, arg := range posts {
+		if _, ok := m.feed(arg.FeedID); arg.FeedID.Valid && !ok {
+			return inserted, errForeignKey
+		}
+		if slices.ContainsFunc(m.posts, func(p Post) bool {
+			return p.ID == arg.ID || arg.Url.Valid && p.Url == arg.Url
+		}) {
+			continue
+		}
+		m.seq++
+		m.posts = append(m.posts, Post{
+			ID:          arg.ID,
+			CreatedAt:   arg.CreatedAt,
+			UpdatedAt:   arg.UpdatedAt,
+			Title:       arg.Title,
+			Url:         arg.Url,
+			Description: arg.Description,
+			PublishedAt: arg.PublishedAt,
+			FeedID:      arg.FeedID,
+			Seq:         m.seq,
+		})
+		inserted++
+	}
+	return inserted, nil
+}
+
+func (m *MemoryStore) CreateSession(ctx context.Context, arg CreateSessionParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.sessions, func(s Session) bool { return s.TokenHash == arg.TokenHash }) {
+		return errUnique("sessions.token_hash")
+	}
+	if _, ok := m.user(arg.UserID); !ok {
+		return errForeignKey
+	}
+	m.sessions = append(m.sessions, Session(arg))
+	return nil
+}
+
+func (m *MemoryStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.users, func(u User) bool { return u.ID == arg.ID }) {
+		return User{}, errUnique("users.id")
+	}
+	if slices.ContainsFunc(m.users, func(u User) bool { return u.Name == arg.Name }) {
+		return User{}, errUnique("users.name")
+	}
+	// The first user becomes an admin.
+	role := "member"
+	if len(m.users) == 0 {
+		role = "admin"
+	}
+	user := User{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		Name:         arg.Name,
+		PasswordHash: arg.PasswordHash,
+		Role:         role,
+	}
+	m.users = append(m.users, user)
+	return user, nil
+}
+
+func (m *MemoryStore) DeleteAllFeeds(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteFeeds(func(Feed) bool { return true })
+	return nil
+}
+
+func (m *MemoryStore) DeleteAllPosts(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletePosts(func(Post) bool { return true })
+	return nil
+}
+
+func (m *MemoryStore) DeleteAllUser(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteUsers(func(User) bool { return true })
+	return nil
+}
+
+func (m *MemoryStore) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteFeeds(func(f Feed) bool { return f.ID == id })
+	return nil
+}
+
+func (m *MemoryStore) DeleteFeedRetention(ctx context.Context, feedID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retention = slices.DeleteFunc(m.retention, func(r FeedRetention) bool { return r.FeedID == feedID })
+	return nil
+}
+
+func (m *MemoryStore) DeleteFeedFollowsByUrl(ctx context.Context, arg DeleteFeedFollowsByUrlParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed, ok := m.feedByURL(arg.Url)
+	if !ok {
+		return nil
+	}
+	m.follows = slices.DeleteFunc(m.follows, func(ff FeedsFollow) bool {
+		return sameID(ff.UserID, arg.UserID) && ff.FeedID.Valid && ff.FeedID.UUID == feed.ID
+	})
+	return nil
+}
+
+func (m *MemoryStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions = slices.DeleteFunc(m.sessions, func(s Session) bool { return s.TokenHash == tokenHash })
+	return nil
+}
+
+func (m *MemoryStore) DeleteSessionsForUser(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions = slices.DeleteFunc(m.sessions, func(s Session) bool { return s.UserID == userID })
+	return nil
+}
+
+func (m *MemoryStore) DeleteUser(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteUsers(func(u User) bool { return u.Name == name })
+	return nil
+}
+
+func (m *MemoryStore) GetFeedByUrl(ctx context.Context, url sql.NullString) (Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed, ok := m.feedByURL(url)
+	if !ok {
+		return Feed{}, sql.ErrNoRows
+	}
+	return feed, nil
+}
+
+func (m *MemoryStore) GetFeedFollowsForUser(ctx context.Context, userID uuid.NullUUID) ([]GetFeedFollowsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []GetFeedFollowsForUserRow{}
+	for _, ff := range m.follows {
+		if !sameID(ff.UserID, userID) {
+			continue
+		}
+		user, userOK := m.user(ff.UserID.UUID)
+		feed, feedOK := m.feed(ff.FeedID)
+		if !userOK || !feedOK {
+			continue
+		}
+		items = append(items, GetFeedFollowsForUserRow{
+			ID:        ff.ID,
+			CreatedAt: ff.CreatedAt,
+			UpdatedAt: ff.UpdatedAt,
+			UserID:    ff.UserID,
+			FeedID:    ff.FeedID,
+			Folder:    ff.Folder,
+			UserName:  user.Name,
+			FeedName:  feed.Name,
+			FeedUrl:   feed.Url,
+		})
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetFeedRetention(ctx context.Context, feedID uuid.UUID) (FeedRetention, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.retention, func(r FeedRetention) bool { return r.FeedID == feedID })
+	if i < 0 {
+		return FeedRetention{}, sql.ErrNoRows
+	}
+	return m.retention[i], nil
+}
+
+func (m *MemoryStore) GetFeedStats(ctx context.Context, url sql.NullString) (GetFeedStatsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed, ok := m.feedByURL(url)
+	if !ok {
+		return GetFeedStatsRow{}, sql.ErrNoRows
+	}
+	i := GetFeedStatsRow{
+		ID:             feed.ID,
+		CreatedAt:      feed.CreatedAt,
+		UpdatedAt:      feed.UpdatedAt,
+		Name:           feed.Name,
+		Url:            feed.Url,
+		UserID:         feed.UserID,
+		LastFetchedAt:  feed.LastFetchedAt,
+		LastFetchError: feed.LastFetchError,
+	}
+	if owner, ok := m.user(feed.UserID.UUID); feed.UserID.Valid && ok {
+		i.OwnerName = sql.NullString{String: owner.Name, Valid: true}
+	}
+	feedID := uuid.NullUUID{UUID: feed.ID, Valid: true}
+	for _, ff := range m.follows {
+		if sameID(ff.FeedID, feedID) {
+			i.Followers++
+		}
+	}
+	for _, p := range m.posts {
+		if sameID(p.FeedID, feedID) {
+			i.Posts++
+		}
+	}
+	return i, nil
+}
+
+func (m *MemoryStore) GetFeverFeedsForUser(ctx context.Context, userID uuid.NullUUID) ([]GetFeverFeedsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []GetFeverFeedsForUserRow{}
+	for _, ff := range m.follows {
+		if !sameID(ff.UserID, userID) {
+			continue
+		}
+		f, ok := m.feed(ff.FeedID)
+		if !ok {
+			continue
+		}
+		items = append(items, GetFeverFeedsForUserRow{
+			ID:             f.ID,
+			CreatedAt:      f.CreatedAt,
+			UpdatedAt:      f.UpdatedAt,
+			Name:           f.Name,
+			Url:            f.Url,
+			UserID:         f.UserID,
+			LastFetchedAt:  f.LastFetchedAt,
+			LastFetchError: f.LastFetchError,
+			Folder:         ff.Folder,
+		})
+	}
+	slices.SortStableFunc(items, func(a, b GetFeverFeedsForUserRow) int {
+		return compareNullString(a.Name, b.Name)
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) GetFeverItem(ctx context.Context, arg GetFeverItemParams) (GetFeverItemRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.userPosts(arg.UserID) {
+		if p.Seq == arg.Seq {
+			return GetFeverItemRow(feverItem(p)), nil
+		}
+	}
+	return GetFeverItemRow{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) GetFeverItemsBefore(ctx context.Context, arg GetFeverItemsBeforeParams) ([]GetFeverItemsBeforeRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool { return p.Seq >= arg.Seq })
+	slices.SortFunc(posts, func(a, b userPost) int { return cmp.Compare(b.Seq, a.Seq) })
+	items := []GetFeverItemsBeforeRow{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, GetFeverItemsBeforeRow(feverItem(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetFeverItemsSince(ctx context.Context, arg GetFeverItemsSinceParams) ([]GetFeverItemsSinceRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool { return p.Seq <= arg.Seq })
+	slices.SortFunc(posts, func(a, b userPost) int { return cmp.Compare(a.Seq, b.Seq) })
+	items := []GetFeverItemsSinceRow{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, GetFeverItemsSinceRow(feverItem(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetFoldersForUser(ctx context.Context, userID uuid.NullUUID) ([]sql.NullString, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []sql.NullString{}
+	for _, ff := range m.follows {
+		if sameID(ff.UserID, userID) && ff.Folder.Valid && !slices.Contains(items, ff.Folder) {
+			items = append(items, ff.Folder)
+		}
+	}
+	slices.SortFunc(items, compareNullString)
+	return items, nil
+}
+
+func (m *MemoryStore) GetNextFeedToFetch(ctx context.Context, userID uuid.NullUUID) (Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.feedsToFetch(func(ff FeedsFollow) bool { return sameID(ff.UserID, userID) }))
+}
+
+func (m *MemoryStore) GetNextFollowedFeedToFetch(ctx context.Context) (Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.feedsToFetch(func(ff FeedsFollow) bool { return true }))
+}
+
+func (m *MemoryStore) ListFeedsToFetch(ctx context.Context, userID uuid.NullUUID) ([]Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.feedsToFetch(func(ff FeedsFollow) bool { return sameID(ff.UserID, userID) }), nil
+}
+
+func (m *MemoryStore) ListFollowedFeedsToFetch(ctx context.Context) ([]Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.feedsToFetch(func(ff FeedsFollow) bool { return true }), nil
+}
+
+// feedsToFetch returns the feeds with a follow that matches, least recently
+// fetched first.
+func (m *MemoryStore) feedsToFetch(match func(FeedsFollow) bool) []Feed {
+	feeds := []Feed{}
+	for _, f := range m.feeds {
+		if slices.ContainsFunc(m.follows, func(ff FeedsFollow) bool {
+			return ff.FeedID.Valid && ff.FeedID.UUID == f.ID && match(ff)
+		}) {
+			feeds = append(feeds, f)
+		}
+	}
+	// NULLS FIRST: feeds that were never fetched go first.
+	slices.SortStableFunc(feeds, func(a, b Feed) int {
+		if a.LastFetchedAt.Valid != b.LastFetchedAt.Valid {
+			if !a.LastFetchedAt.Valid {
+				return -1
+			}
+			return 1
+		}
+		return compareNullTime(a.LastFetchedAt, b.LastFetchedAt)
+	})
+	return feeds
+}
+
+func (m *MemoryStore) GetPostByUrl(ctx context.Context, url sql.NullString) (Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.posts, func(p Post) bool { return url.Valid && p.Url == url })
+	if i < 0 {
+		return Post{}, sql.ErrNoRows
+	}
+	return m.posts[i], nil
+}
+
+func (m *MemoryStore) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.userPosts(arg.UserID) {
+		if p.ID == arg.ID {
+			return GetPostForUserRow(postWithFeed(p)), nil
+		}
+	}
+	return GetPostForUserRow{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) GetPostsCreatedAfterForUser(ctx context.Context, arg GetPostsCreatedAfterForUserParams) ([]GetPostsCreatedAfterForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool {
+		return !p.CreatedAt.Valid || !arg.CreatedAt.Valid || !p.CreatedAt.Time.After(arg.CreatedAt.Time)
+	})
+	slices.SortStableFunc(posts, func(a, b userPost) int { return compareNullTime(a.CreatedAt, b.CreatedAt) })
+	items := []GetPostsCreatedAfterForUserRow{}
+	for _, p := range posts {
+		items = append(items, GetPostsCreatedAfterForUserRow(postWithFeed(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := m.userPosts(arg.UserID)
+	sortByPublishedAt(posts)
+	items := []Post{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, p.Post)
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetPostsWithStateForFeed(ctx context.Context, arg GetPostsWithStateForFeedParams) ([]GetPostsWithStateForFeedRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool {
+		return !sameID(p.FeedID, arg.FeedID)
+	})
+	sortByPublishedAt(posts)
+	items := []GetPostsWithStateForFeedRow{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, GetPostsWithStateForFeedRow(postWithState(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetPostsWithStateForFolder(ctx context.Context, arg GetPostsWithStateForFolderParams) ([]GetPostsWithStateForFolderRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool {
+		return !arg.Folder.Valid || p.follow.Folder != arg.Folder
+	})
+	sortByPublishedAt(posts)
+	items := []GetPostsWithStateForFolderRow{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, GetPostsWithStateForFolderRow(postWithState(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetPostsWithStateForUser(ctx context.Context, arg GetPostsWithStateForUserParams) ([]GetPostsWithStateForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := m.userPosts(arg.UserID)
+	sortByPublishedAt(posts)
+	items := []GetPostsWithStateForUserRow{}
+	for _, p := range limit(posts, arg.Limit, 0) {
+		items = append(items, GetPostsWithStateForUserRow(postWithState(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetSessionUser(ctx context.Context, arg GetSessionUserParams) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.TokenHash == arg.TokenHash && s.ExpiresAt.After(arg.ExpiresAt) {
+			if user, ok := m.user(s.UserID); ok {
+				return user, nil
+			}
+		}
+	}
+	return User{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) GetStarredPostSeqsForUser(ctx context.Context, userID uuid.NullUUID) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []int64{}
+	for _, p := range m.userPosts(userID) {
+		if p.state.StarredAt.Valid {
+			items = append(items, p.Seq)
+		}
+	}
+	slices.Sort(items)
+	return items, nil
+}
+
+func (m *MemoryStore) GetUnreadCountsForUser(ctx context.Context, userID uuid.NullUUID) ([]GetUnreadCountsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []GetUnreadCountsForUserRow{}
+	for _, p := range m.userPosts(userID) {
+		if p.state.ReadAt.Valid {
+			continue
+		}
+		i := slices.IndexFunc(items, func(c GetUnreadCountsForUserRow) bool { return c.FeedID == p.FeedID })
+		if i < 0 {
+			items = append(items, GetUnreadCountsForUserRow{FeedID: p.FeedID})
+			i = len(items) - 1
+		}
+		items[i].Unread++
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetUnreadPostIDsBefore(ctx context.Context, arg GetUnreadPostIDsBeforeParams) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []uuid.UUID{}
+	for _, p := range m.userPosts(arg.UserID) {
+		if p.state.ReadAt.Valid ||
+			arg.FeedID.Valid && !sameID(p.FeedID, arg.FeedID) ||
+			arg.Folder.Valid && p.follow.Folder != arg.Folder ||
+			!p.CreatedAt.Valid || !arg.Before.Valid || !p.CreatedAt.Time.Before(arg.Before.Time) {
+			continue
+		}
+		items = append(items, p.ID)
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) GetUnreadPostSeqsForUser(ctx context.Context, userID uuid.NullUUID) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []int64{}
+	for _, p := range m.userPosts(userID) {
+		if !p.state.ReadAt.Valid {
+			items = append(items, p.Seq)
+		}
+	}
+	slices.Sort(items)
+	return items, nil
+}
+
+func (m *MemoryStore) GetUser(ctx context.Context, name string) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.users, func(u User) bool { return u.Name == name })
+	if i < 0 {
+		return User{}, sql.ErrNoRows
+	}
+	return m.users[i], nil
+}
+
+func (m *MemoryStore) GetUserByFeverKey(ctx context.Context, feverKey sql.NullString) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.users, func(u User) bool { return feverKey.Valid && u.FeverKey == feverKey })
+	if i < 0 {
+		return User{}, sql.ErrNoRows
+	}
+	return m.users[i], nil
+}
+
+func (m *MemoryStore) GetUsers(ctx context.Context) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.users), nil
+}
+
+// InTx runs fn on a copy of the tables and keeps the copy only when fn
+// succeeds. Like a SQLite transaction it holds the store until fn returns,
+// so other callers wait instead of seeing half of fn's changes or having
+// theirs undone with it.
+func (m *MemoryStore) InTx(ctx context.Context, fn func(Store) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &MemoryStore{memoryTables: m.memoryTables.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	m.memoryTables = tx.memoryTables
+	return nil
+}
+
+// InSnapshot runs fn on a copy of the tables, so it doesn't hold up other
+// callers. Whatever fn changes is thrown away.
+func (m *MemoryStore) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	m.mu.Lock()
+	snapshot := &MemoryStore{memoryTables: m.memoryTables.clone()}
+	m.mu.Unlock()
+	return fn(snapshot)
+}
+
+func (m *MemoryStore) ListAllFeedFollows(ctx context.Context) ([]FeedsFollow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.follows)
+	slices.SortStableFunc(items, func(a, b FeedsFollow) int {
+		return cmp.Or(compareNullTime(a.CreatedAt, b.CreatedAt), compareID(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListAllFeedRetention(ctx context.Context) ([]FeedRetention, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.retention)
+	slices.SortStableFunc(items, func(a, b FeedRetention) int { return compareID(a.FeedID, b.FeedID) })
+	return items, nil
+}
+
+func (m *MemoryStore) ListAllFeeds(ctx context.Context) ([]Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.feeds)
+	slices.SortStableFunc(items, func(a, b Feed) int {
+		return cmp.Or(compareNullTime(a.CreatedAt, b.CreatedAt), compareID(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListAllPostStates(ctx context.Context) ([]PostsState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.states)
+	slices.SortStableFunc(items, func(a, b PostsState) int {
+		return cmp.Or(compareID(a.UserID, b.UserID), compareID(a.PostID, b.PostID))
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListAllSessions(ctx context.Context) ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.sessions)
+	slices.SortStableFunc(items, func(a, b Session) int {
+		return cmp.Or(compareNullTime(a.CreatedAt, b.CreatedAt), cmp.Compare(a.TokenHash, b.TokenHash))
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListAllUsers(ctx context.Context) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.Clone(m.users)
+	slices.SortStableFunc(items, func(a, b User) int {
+		return cmp.Or(compareNullTime(a.CreatedAt, b.CreatedAt), compareID(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListPostsAfter(ctx context.Context, arg ListPostsAfterParams) ([]Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := slices.DeleteFunc(slices.Clone(m.posts), func(p Post) bool { return p.Seq <= arg.Seq })
+	slices.SortFunc(items, func(a, b Post) int { return cmp.Compare(a.Seq, b.Seq) })
+	return limit(items, arg.Limit, 0), nil
+}
+
+func (m *MemoryStore) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []ListFeedsRow{}
+	for _, f := range m.feeds {
+		i := ListFeedsRow{Name: f.Name, Url: f.Url}
+		if owner, ok := m.user(f.UserID.UUID); f.UserID.Valid && ok {
+			i.UserName = sql.NullString{String: owner.Name, Valid: true}
+		}
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) ListFeedsWithRetention(ctx context.Context) ([]ListFeedsWithRetentionRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []ListFeedsWithRetentionRow{}
+	for _, f := range m.feeds {
+		i := ListFeedsWithRetentionRow{ID: f.ID, Name: f.Name, Url: f.Url}
+		if j := slices.IndexFunc(m.retention, func(r FeedRetention) bool { return r.FeedID == f.ID }); j >= 0 {
+			r := m.retention[j]
+			i.MaxAge, i.MaxPosts, i.KeepUnread, i.KeepStarred = r.MaxAge, r.MaxPosts, r.KeepUnread, r.KeepStarred
+		}
+		items = append(items, i)
+	}
+	slices.SortStableFunc(items, func(a, b ListFeedsWithRetentionRow) int {
+		return compareNullString(a.Name, b.Name)
+	})
+	return items, nil
+}
+
+func (m *MemoryStore) ListPostsForUser(ctx context.Context, arg ListPostsForUserParams) ([]ListPostsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var pattern *regexp.Regexp
+	if arg.Pattern.Valid {
+		pattern = likePattern(arg.Pattern.String)
+	}
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool {
+		return arg.FeedID.Valid && !sameID(p.FeedID, arg.FeedID) ||
+			arg.Folder.Valid && p.follow.Folder != arg.Folder ||
+			pattern != nil && !matchNullString(pattern, p.Title) && !matchNullString(pattern, p.Description) ||
+			arg.Unread.Valid && p.state.ReadAt.Valid == arg.Unread.Bool ||
+			arg.Starred.Valid && p.state.StarredAt.Valid != arg.Starred.Bool
+	})
+	sortByPublishedAt(posts)
+	items := []ListPostsForUserRow{}
+	for _, p := range limit(posts, arg.PageLimit, arg.PageOffset) {
+		items = append(items, ListPostsForUserRow(postWithState(p)))
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		if m.feeds[i].ID == arg.ID {
+			m.feeds[i].LastFetchedAt = arg.LastFetchedAt
+			m.feeds[i].UpdatedAt = arg.LastFetchedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) PrunePosts(ctx context.Context, arg PrunePostsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := m.prunable(CountPrunablePostsParams(arg))
+	m.deletePosts(func(p Post) bool { return slices.Contains(ids, p.ID) })
+	return int64(len(ids)), nil
+}
+
+// prunable returns the posts of a feed that are older than arg.Before or
+// beyond the newest arg.MaxPosts, apart from the ones that are kept for
+// being unread or starred.
+func (m *MemoryStore) prunable(arg CountPrunablePostsParams) []uuid.UUID {
+	postedAt := func(p Post) sql.NullTime {
+		if p.PublishedAt.Valid {
+			return p.PublishedAt
+		}
+		return p.CreatedAt
+	}
+	posts := slices.DeleteFunc(slices.Clone(m.posts), func(p Post) bool { return !sameID(p.FeedID, arg.FeedID) })
+	slices.SortStableFunc(posts, func(a, b Post) int { return compareNullTime(postedAt(b), postedAt(a)) })
+
+	ids := []uuid.UUID{}
+	for position, p := range posts {
+		posted := postedAt(p)
+		tooOld := posted.Valid && arg.Before.Valid && posted.Time.Before(arg.Before.Time)
+		tooMany := arg.MaxPosts.Valid && int64(position+1) > arg.MaxPosts.Int64
+		if !tooOld && !tooMany {
+			continue
+		}
+		if arg.KeepUnread && slices.ContainsFunc(m.follows, func(ff FeedsFollow) bool {
+			return sameID(ff.FeedID, arg.FeedID) && !m.state(ff.UserID.UUID, p.ID).ReadAt.Valid
+		}) {
+			continue
+		}
+		if arg.KeepStarred && slices.ContainsFunc(m.states, func(ps PostsState) bool {
+			return ps.PostID == p.ID && ps.StarredAt.Valid
+		}) {
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func (m *MemoryStore) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		if m.feeds[i].ID == arg.ID {
+			m.feeds[i].Name = arg.Name
+			m.feeds[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if arg.NewName != arg.OldName && slices.ContainsFunc(m.users, func(u User) bool { return u.Name == arg.NewName }) {
+		return errUnique("users.name")
+	}
+	for i := range m.users {
+		if m.users[i].Name == arg.OldName {
+			m.users[i].Name = arg.NewName
+			m.users[i].FeverKey = sql.NullString{}
+			m.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) ResetFeedsFetched(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		m.feeds[i].LastFetchedAt = sql.NullTime{}
+	}
+	return nil
+}
+
+// The Restore methods skip rows that break a unique constraint, like
+// ON CONFLICT DO NOTHING, and return the number of rows they inserted.
+
+func (m *MemoryStore) RestoreFeed(ctx context.Context, arg RestoreFeedParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.user(arg.UserID.UUID); arg.UserID.Valid && !ok {
+		return 0, errForeignKey
+	}
+	_, urlTaken := m.feedByURL(arg.Url)
+	if urlTaken || slices.ContainsFunc(m.feeds, func(f Feed) bool { return f.ID == arg.ID }) {
+		return 0, nil
+	}
+	m.feeds = append(m.feeds, Feed(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) RestoreFeedFollow(ctx context.Context, arg RestoreFeedFollowParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, userOK := m.user(arg.UserID.UUID)
+	_, feedOK := m.feed(arg.FeedID)
+	if arg.UserID.Valid && !userOK || arg.FeedID.Valid && !feedOK {
+		return 0, errForeignKey
+	}
+	if slices.ContainsFunc(m.follows, func(ff FeedsFollow) bool {
+		return ff.ID == arg.ID || sameID(ff.UserID, arg.UserID) && sameID(ff.FeedID, arg.FeedID)
+	}) {
+		return 0, nil
+	}
+	m.follows = append(m.follows, FeedsFollow(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) RestoreFeedRetention(ctx context.Context, arg RestoreFeedRetentionParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.feed(uuid.NullUUID{UUID: arg.FeedID, Valid: true}); !ok {
+		return 0, errForeignKey
+	}
+	if slices.ContainsFunc(m.retention, func(r FeedRetention) bool { return r.FeedID == arg.FeedID }) {
+		return 0, nil
+	}
+	m.retention = append(m.retention, FeedRetention(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) RestorePost(ctx context.Context, arg RestorePostParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.feed(arg.FeedID); arg.FeedID.Valid && !ok {
+		return 0, errForeignKey
+	}
+	if slices.ContainsFunc(m.posts, func(p Post) bool {
+		return p.ID == arg.ID || arg.Url.Valid && p.Url == arg.Url
+	}) {
+		return 0, nil
+	}
+	m.seq++
+	m.posts = append(m.posts, Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Seq:         m.seq,
+	})
+	return 1, nil
+}
+
+func (m *MemoryStore) RestorePostState(ctx context.Context, arg RestorePostStateParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.user(arg.UserID); !ok || !slices.ContainsFunc(m.posts, func(p Post) bool { return p.ID == arg.PostID }) {
+		return 0, errForeignKey
+	}
+	if slices.ContainsFunc(m.states, func(ps PostsState) bool {
+		return ps.UserID == arg.UserID && ps.PostID == arg.PostID
+	}) {
+		return 0, nil
+	}
+	m.states = append(m.states, PostsState(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) RestoreSession(ctx context.Context, arg RestoreSessionParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.user(arg.UserID); !ok {
+		return 0, errForeignKey
+	}
+	if slices.ContainsFunc(m.sessions, func(s Session) bool { return s.TokenHash == arg.TokenHash }) {
+		return 0, nil
+	}
+	m.sessions = append(m.sessions, Session(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.users, func(u User) bool {
+		return u.ID == arg.ID || u.Name == arg.Name || arg.FeverKey.Valid && u.FeverKey == arg.FeverKey
+	}) {
+		return 0, nil
+	}
+	m.users = append(m.users, User(arg))
+	return 1, nil
+}
+
+func (m *MemoryStore) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pattern := likePattern(arg.Pattern)
+	posts := slices.DeleteFunc(m.userPosts(arg.UserID), func(p userPost) bool {
+		return !matchNullString(pattern, p.Title) && !matchNullString(pattern, p.Description)
+	})
+	sortByPublishedAt(posts)
+	items := []Post{}
+	for _, p := range limit(posts, arg.ResultLimit, 0) {
+		items = append(items, p.Post)
+	}
+	return items, nil
+}
+
+func (m *MemoryStore) SetFeedFetchError(ctx context.Context, arg SetFeedFetchErrorParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		if m.feeds[i].ID == arg.ID {
+			m.feeds[i].LastFetchError = arg.LastFetchError
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed, ok := m.feedByURL(arg.Url)
+	if !ok {
+		return 0, nil
+	}
+	var n int64
+	for i, ff := range m.follows {
+		if sameID(ff.UserID, arg.UserID) && ff.FeedID.Valid && ff.FeedID.UUID == feed.ID {
+			m.follows[i].Folder = arg.Folder
+			m.follows[i].UpdatedAt = arg.UpdatedAt
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.feed(uuid.NullUUID{UUID: arg.FeedID, Valid: true}); !ok {
+		return errForeignKey
+	}
+	r := FeedRetention(arg)
+	if i := slices.IndexFunc(m.retention, func(r FeedRetention) bool { return r.FeedID == arg.FeedID }); i >= 0 {
+		m.retention[i] = r
+	} else {
+		m.retention = append(m.retention, r)
+	}
+	return nil
+}
+
+func (m *MemoryStore) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.feedByURL(arg.Url); ok && f.ID != arg.ID {
+		return errUnique("feeds.url")
+	}
+	for i := range m.feeds {
+		if m.feeds[i].ID == arg.ID {
+			m.feeds[i].Url = arg.Url
+			m.feeds[i].LastFetchError = sql.NullString{}
+			m.feeds[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) SetPostRead(ctx context.Context, arg SetPostReadParams) error {
+	return m.setPostState(arg.UserID, arg.PostID, func(ps *PostsState) { ps.ReadAt = arg.ReadAt })
+}
+
+func (m *MemoryStore) SetPostStarred(ctx context.Context, arg SetPostStarredParams) error {
+	return m.setPostState(arg.UserID, arg.PostID, func(ps *PostsState) { ps.StarredAt = arg.StarredAt })
+}
+
+// setPostState upserts the marks of a post for a user.
+func (m *MemoryStore) setPostState(userID, postID uuid.UUID, set func(*PostsState)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.states, func(ps PostsState) bool {
+		return ps.UserID == userID && ps.PostID == postID
+	})
+	if i < 0 {
+		if _, ok := m.user(userID); !ok || !slices.ContainsFunc(m.posts, func(p Post) bool { return p.ID == postID }) {
+			return errForeignKey
+		}
+		m.states = append(m.states, PostsState{UserID: userID, PostID: postID})
+		i = len(m.states) - 1
+	}
+	set(&m.states[i])
+	return nil
+}
+
+func (m *MemoryStore) SetUserFeverKey(ctx context.Context, arg SetUserFeverKeyParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.users, func(u User) bool {
+		return arg.FeverKey.Valid && u.FeverKey == arg.FeverKey && u.ID != arg.ID
+	}) {
+		return errUnique("users.fever_key")
+	}
+	for i := range m.users {
+		if m.users[i].ID == arg.ID {
+			m.users[i].FeverKey = arg.FeverKey
+			m.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.users {
+		if m.users[i].ID == arg.ID {
+			m.users[i].PasswordHash = arg.PasswordHash
+			m.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if arg.Role != "admin" && arg.Role != "member" {
+		return errors.New("CHECK constraint failed: role")
+	}
+	for i := range m.users {
+		if m.users[i].ID == arg.ID {
+			m.users[i].Role = arg.Role
+			m.users[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+// The rows of the post queries share their columns, so they convert from
+// these.
+type feverItemRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+type postWithStateRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+type postWithFeedRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	FeedUrl     sql.NullString
+	Folder      sql.NullString
+}
+
+func feverItem(p userPost) feverItemRow {
+	return feverItemRow{
+		ID:          p.ID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Title:       p.Title,
+		Url:         p.Url,
+		Description: p.Description,
+		PublishedAt: p.PublishedAt,
+		FeedID:      p.FeedID,
+		Seq:         p.Seq,
+		ReadAt:      p.state.ReadAt,
+		StarredAt:   p.state.StarredAt,
+	}
+}
+
+func postWithState(p userPost) postWithStateRow {
+	return postWithStateRow{
+		ID:          p.ID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Title:       p.Title,
+		Url:         p.Url,
+		Description: p.Description,
+		PublishedAt: p.PublishedAt,
+		FeedID:      p.FeedID,
+		Seq:         p.Seq,
+		FeedName:    p.feed.Name,
+		ReadAt:      p.state.ReadAt,
+		StarredAt:   p.state.StarredAt,
+	}
+}
+
+func postWithFeed(p userPost) postWithFeedRow {
+	return postWithFeedRow{
+		ID:          p.ID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Title:       p.Title,
+		Url:         p.Url,
+		Description: p.Description,
+		PublishedAt: p.PublishedAt,
+		FeedID:      p.FeedID,
+		Seq:         p.Seq,
+		FeedName:    p.feed.Name,
+		FeedUrl:     p.feed.Url,
+		Folder:      p.follow.Folder,
+	}
+}
+
+func sameID(a, b uuid.NullUUID) bool {
+	return a.Valid && b.Valid && a.UUID == b.UUID
+}
+
+// compareNullTime sorts NULL after every time, like PostgreSQL does.
+func compareID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func compareNullTime(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return 1
+	case !b.Valid:
+		return -1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+func compareNullString(a, b sql.NullString) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return 1
+	case !b.Valid:
+		return -1
+	}
+	return strings.Compare(a.String, b.String)
+}
+
+// sortByPublishedAt orders posts by published_at DESC.
+func sortByPublishedAt(posts []userPost) {
+	slices.SortStableFunc(posts, func(a, b userPost) int {
+		return compareNullTime(b.PublishedAt, a.PublishedAt)
+	})
+}
+
+// first returns the first row like a :one query, sql.ErrNoRows when there
+// is none.
+func first[T any](rows []T) (T, error) {
+	if len(rows) == 0 {
+		var zero T
+		return zero, sql.ErrNoRows
+	}
+	return rows[0], nil
+}
+
+func limit[T any](rows []T, n, offset int32) []T {
+	if int(offset) >= len(rows) {
+		return nil
+	}
+	rows = rows[max(offset, 0):]
+	if int(n) < len(rows) {
+		rows = rows[:max(n, 0)]
+	}
+	return rows
+}
+
+// likePattern compiles a case insensitive LIKE pattern, where % matches any
+// text, _ any character and \ escapes the next character.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func matchNullString(pattern *regexp.Regexp, s sql.NullString) bool {
+	return s.Valid && pattern.MatchString(s.String)
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func createTestUser(ctx context.Context, store Store, name string) error {
+	_, err := store.CreateUser(ctx, CreateUserParams{ID: uuid.New(), Name: name})
+	return err
+}
+
+func TestMemoryInTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	errFail := errors.New("fail")
+	err := m.InTx(ctx, func(tx Store) error {
+		if err := createTestUser(ctx, tx, "alice"); err != nil {
+			return err
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("InTx returned %v, want %v", err, errFail)
+	}
+	if users, _ := m.GetUsers(ctx); len(users) != 0 {
+		t.Errorf("a failed transaction left %d users", len(users))
+	}
+
+	err = m.InTx(ctx, func(tx Store) error {
+		return createTestUser(ctx, tx, "alice")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetUser(ctx, "alice"); err != nil {
+		t.Errorf("the committed user is missing: %v", err)
+	}
+}
+
+func TestMemoryInTxConcurrent(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	// Transactions run one at a time, so none of them loses the users
+	// another one committed meanwhile, and plain queries wait for them.
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- m.InTx(ctx, func(tx Store) error {
+				return createTestUser(ctx, tx, fmt.Sprintf("tx%d", i))
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- createTestUser(ctx, m, fmt.Sprintf("plain%d", i))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, err := m.GetUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2*n {
+		t.Errorf("%d users after %d concurrent inserts", len(users), 2*n)
+	}
+}
+
+func TestMemoryInSnapshot(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	if err := createTestUser(ctx, m, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.InSnapshot(ctx, func(snapshot Store) error {
+		// Writes to the store while the snapshot is read don't show up in
+		// it, and don't wait for it.
+		if err := createTestUser(ctx, m, "bob"); err != nil {
+			return err
+		}
+		users, err := snapshot.GetUsers(ctx)
+		if err != nil {
+			return err
+		}
+		if len(users) != 1 {
+			t.Errorf("the snapshot has %d users, want 1", len(users))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := m.GetUsers(ctx); len(users) != 2 {
+		t.Errorf("%d users after the snapshot, want 2", len(users))
+	}
+}
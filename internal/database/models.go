@@ -6,18 +6,28 @@ package database
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	Name          sql.NullString
-	Url           sql.NullString
-	UserID        uuid.NullUUID
-	LastFetchedAt sql.NullTime
+	ID             uuid.UUID
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Name           sql.NullString
+	Url            sql.NullString
+	UserID         uuid.NullUUID
+	LastFetchedAt  sql.NullTime
+	LastFetchError sql.NullString
+}
+
+type FeedRetention struct {
+	FeedID      uuid.UUID
+	MaxAge      sql.NullInt64
+	MaxPosts    sql.NullInt32
+	KeepUnread  sql.NullBool
+	KeepStarred sql.NullBool
 }
 
 type FeedsFollow struct {
@@ -26,6 +36,7 @@ type FeedsFollow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.NullUUID
 	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 }
 
 type Post struct {
@@ -37,11 +48,29 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.NullUUID
+	Seq         int64
 }
 
-type User struct {
-	ID        uuid.UUID
+type PostsState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+	StarredAt sql.NullTime
+}
+
+type Session struct {
+	TokenHash string
+	UserID    uuid.UUID
 	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	Name      string
+	ExpiresAt time.Time
+}
+
+type User struct {
+	ID           uuid.UUID
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+	Name         string
+	FeverKey     sql.NullString
+	PasswordHash sql.NullString
+	Role         string
 }
@@ -51,8 +51,138 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 	return err
 }
 
+const deleteAllPosts = `-- name: DeleteAllPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) DeleteAllPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPosts)
+	return err
+}
+
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    ff.folder
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = $1
+    AND p.id = $2
+`
+
+type GetPostForUserParams struct {
+	UserID uuid.NullUUID
+	ID     uuid.UUID
+}
+
+type GetPostForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	FeedUrl     sql.NullString
+	Folder      sql.NullString
+}
+
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.UserID, arg.ID)
+	var i GetPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Seq,
+		&i.FeedName,
+		&i.FeedUrl,
+		&i.Folder,
+	)
+	return i, err
+}
+
+const getPostsCreatedAfterForUser = `-- name: GetPostsCreatedAfterForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    ff.folder
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = $1
+    AND p.created_at > $2
+ORDER BY p.created_at ASC
+`
+
+type GetPostsCreatedAfterForUserParams struct {
+	UserID    uuid.NullUUID
+	CreatedAt sql.NullTime
+}
+
+type GetPostsCreatedAfterForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	FeedUrl     sql.NullString
+	Folder      sql.NullString
+}
+
+func (q *Queries) GetPostsCreatedAfterForUser(ctx context.Context, arg GetPostsCreatedAfterForUserParams) ([]GetPostsCreatedAfterForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsCreatedAfterForUser, arg.UserID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsCreatedAfterForUserRow
+	for rows.Next() {
+		var i GetPostsCreatedAfterForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.Folder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq
 FROM posts p
 WHERE p.feed_id IN (
         SELECT ff.feed_id
@@ -86,6 +216,80 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsWithStateForFeed = `-- name: GetPostsWithStateForFeed :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND p.feed_id = $2
+ORDER BY p.published_at DESC
+LIMIT $3
+`
+
+type GetPostsWithStateForFeedParams struct {
+	UserID uuid.NullUUID
+	FeedID uuid.NullUUID
+	Limit  int32
+}
+
+type GetPostsWithStateForFeedRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetPostsWithStateForFeed(ctx context.Context, arg GetPostsWithStateForFeedParams) ([]GetPostsWithStateForFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsWithStateForFeed, arg.UserID, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsWithStateForFeedRow
+	for rows.Next() {
+		var i GetPostsWithStateForFeedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
 		); err != nil {
 			return nil, err
 		}
@@ -99,3 +303,388 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getPostsWithStateForFolder = `-- name: GetPostsWithStateForFolder :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ff.folder = $2
+ORDER BY p.published_at DESC
+LIMIT $3
+`
+
+type GetPostsWithStateForFolderParams struct {
+	UserID uuid.NullUUID
+	Folder sql.NullString
+	Limit  int32
+}
+
+type GetPostsWithStateForFolderRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetPostsWithStateForFolder(ctx context.Context, arg GetPostsWithStateForFolderParams) ([]GetPostsWithStateForFolderRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsWithStateForFolder, arg.UserID, arg.Folder, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsWithStateForFolderRow
+	for rows.Next() {
+		var i GetPostsWithStateForFolderRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsWithStateForUser = `-- name: GetPostsWithStateForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+ORDER BY p.published_at DESC
+LIMIT $2
+`
+
+type GetPostsWithStateForUserParams struct {
+	UserID uuid.NullUUID
+	Limit  int32
+}
+
+type GetPostsWithStateForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetPostsWithStateForUser(ctx context.Context, arg GetPostsWithStateForUserParams) ([]GetPostsWithStateForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsWithStateForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsWithStateForUserRow
+	for rows.Next() {
+		var i GetPostsWithStateForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadCountsForUser = `-- name: GetUnreadCountsForUser :many
+SELECT p.feed_id,
+    COUNT(*) AS unread
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ps.read_at IS NULL
+GROUP BY p.feed_id
+`
+
+type GetUnreadCountsForUserRow struct {
+	FeedID uuid.NullUUID
+	Unread int64
+}
+
+func (q *Queries) GetUnreadCountsForUser(ctx context.Context, userID uuid.NullUUID) ([]GetUnreadCountsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadCountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadCountsForUserRow
+	for rows.Next() {
+		var i GetUnreadCountsForUserRow
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.Unread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsForUser = `-- name: ListPostsForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.seq,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND (
+        $2 IS NULL
+        OR p.feed_id = $2
+    )
+    AND (
+        $3 IS NULL
+        OR ff.folder = $3
+    )
+    AND (
+        $4 IS NULL
+        OR LOWER(p.title) LIKE LOWER($4) ESCAPE '\'
+        OR LOWER(p.description) LIKE LOWER($4) ESCAPE '\'
+    )
+    AND (
+        $5 IS NULL
+        OR (ps.read_at IS NULL) = $5
+    )
+    AND (
+        $6 IS NULL
+        OR (ps.starred_at IS NOT NULL) = $6
+    )
+ORDER BY p.published_at DESC
+LIMIT $7 OFFSET $8
+`
+
+type ListPostsForUserParams struct {
+	UserID     uuid.NullUUID
+	FeedID     uuid.NullUUID
+	Folder     sql.NullString
+	Pattern    sql.NullString
+	Unread     sql.NullBool
+	Starred    sql.NullBool
+	PageLimit  int32
+	PageOffset int32
+}
+
+type ListPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Seq         int64
+	FeedName    sql.NullString
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) ListPostsForUser(ctx context.Context, arg ListPostsForUserParams) ([]ListPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsForUser,
+		arg.UserID,
+		arg.FeedID,
+		arg.Folder,
+		arg.Pattern,
+		arg.Unread,
+		arg.Starred,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsForUserRow
+	for rows.Next() {
+		var i ListPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq
+FROM posts p
+WHERE p.feed_id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+        WHERE ff.user_id = $1
+    )
+    AND (
+        LOWER(p.title) LIKE LOWER($2) ESCAPE '\'
+        OR LOWER(p.description) LIKE LOWER($2) ESCAPE '\'
+    )
+ORDER BY p.published_at DESC
+LIMIT $3
+`
+
+type SearchPostsForUserParams struct {
+	UserID      uuid.NullUUID
+	Pattern     string
+	ResultLimit int32
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser, arg.UserID, arg.Pattern, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostRead = `-- name: SetPostRead :exec
+INSERT INTO posts_state (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = excluded.read_at
+`
+
+type SetPostReadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt sql.NullTime
+}
+
+func (q *Queries) SetPostRead(ctx context.Context, arg SetPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, setPostRead, arg.UserID, arg.PostID, arg.ReadAt)
+	return err
+}
+
+const setPostStarred = `-- name: SetPostStarred :exec
+INSERT INTO posts_state (user_id, post_id, starred_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred_at = excluded.starred_at
+`
+
+type SetPostStarredParams struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt sql.NullTime
+}
+
+func (q *Queries) SetPostStarred(ctx context.Context, arg SetPostStarredParams) error {
+	_, err := q.db.ExecContext(ctx, setPostStarred, arg.UserID, arg.PostID, arg.StarredAt)
+	return err
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// postsPerInsert keeps a multi-row insert well below the bind parameter
+// limits of PostgreSQL (65535) and SQLite (32766).
+const postsPerInsert = 500
+
+const createPosts = `INSERT INTO posts (
+        id,
+        created_at,
+        updated_at,
+        title,
+        url,
+        description,
+        published_at,
+        feed_id
+    )
+VALUES `
+
+// CreatePosts saves posts with multi-row inserts, skipping posts whose URL
+// is already saved, and returns how many it saved. sqlc can't generate an
+// insert with a variable number of rows, so it is written by hand. Run it
+// in InTx to save all posts or none.
+func (q *Queries) CreatePosts(ctx context.Context, posts []CreatePostParams) (int64, error) {
+	var inserted int64
+	for batch := range slices.Chunk(posts, postsPerInsert) {
+		var query strings.Builder
+		query.WriteString(createPosts)
+		args := make([]any, 0, len(batch)*8)
+		for i, p := range batch {
+			if i > 0 {
+				query.WriteString(",\n    ")
+			}
+			n := len(args)
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+			args = append(args,
+				p.ID,
+				p.CreatedAt,
+				p.UpdatedAt,
+				p.Title,
+				p.Url,
+				p.Description,
+				p.PublishedAt,
+				p.FeedID,
+			)
+		}
+		query.WriteString("\nON CONFLICT DO NOTHING")
+
+		result, err := q.db.ExecContext(ctx, query.String(), args...)
+		if err != nil {
+			return inserted, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return inserted, err
+		}
+		inserted += n
+	}
+	return inserted, nil
+}
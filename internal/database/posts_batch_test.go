@@ -0,0 +1,87 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babanini95/gatorcli/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestCreatePosts(t *testing.T) {
+	for _, dbURL := range []string{"memory://", "sqlite:" + filepath.Join(t.TempDir(), "gator.db")} {
+		driver, _, _ := strings.Cut(dbURL, ":")
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := openBench(ctx, dbURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := seed(ctx, db, benchOptions{feeds: 1, follows: 1}); err != nil {
+				t.Fatal(err)
+			}
+			feed, err := db.GetFeedByUrl(ctx, sql.NullString{String: "https://example.com/feed/0.xml", Valid: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			now := time.Now().UTC()
+			newPosts := func(from, to int) []database.CreatePostParams {
+				posts := []database.CreatePostParams{}
+				for i := from; i < to; i++ {
+					posts = append(posts, database.CreatePostParams{
+						ID:          uuid.New(),
+						CreatedAt:   sql.NullTime{Time: now, Valid: true},
+						UpdatedAt:   sql.NullTime{Time: now, Valid: true},
+						Title:       sql.NullString{String: fmt.Sprintf("Post %d", i), Valid: true},
+						Url:         sql.NullString{String: fmt.Sprintf("https://example.com/post/%d", i), Valid: true},
+						PublishedAt: sql.NullTime{Time: now, Valid: true},
+						FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+					})
+				}
+				return posts
+			}
+			countPosts := func() int64 {
+				t.Helper()
+				counts, err := db.CountRows(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return counts.Posts
+			}
+
+			// More posts than fit in one insert.
+			if inserted, err := db.CreatePosts(ctx, newPosts(0, 1200)); err != nil || inserted != 1200 {
+				t.Fatalf("CreatePosts saved %d posts: %v", inserted, err)
+			}
+			// The posts already saved are skipped.
+			if inserted, err := db.CreatePosts(ctx, newPosts(1190, 1210)); err != nil || inserted != 10 {
+				t.Errorf("CreatePosts saved %d of 20 posts, want the 10 new ones: %v", inserted, err)
+			}
+
+			errFail := errors.New("fail")
+			err = db.InTx(ctx, func(tx database.Store) error {
+				if _, err := tx.CreatePosts(ctx, newPosts(2000, 2600)); err != nil {
+					return err
+				}
+				return errFail
+			})
+			if !errors.Is(err, errFail) {
+				t.Fatalf("InTx returned %v, want %v", err, errFail)
+			}
+			if n := countPosts(); n != 1210 {
+				t.Errorf("after a failed transaction there are %d posts, want 1210", n)
+			}
+
+			if inserted, err := db.CreatePosts(ctx, nil); err != nil || inserted != 0 {
+				t.Errorf("CreatePosts without posts saved %d: %v", inserted, err)
+			}
+		})
+	}
+}
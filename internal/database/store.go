@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store is the storage behind the gator commands. Queries implements it on
+// top of PostgreSQL or SQLite and MemoryStore keeps everything in memory.
+type Store interface {
+	Querier
+	// CreatePosts saves posts in bulk, skipping those already saved, and
+	// returns how many it saved.
+	CreatePosts(ctx context.Context, posts []CreatePostParams) (int64, error)
+	// InTx runs fn with a Store whose changes are kept only if fn returns
+	// nil.
+	InTx(ctx context.Context, fn func(Store) error) error
+	// InSnapshot runs fn with a read-only Store that sees the database as
+	// it was when fn started, whatever other processes write meanwhile.
+	InSnapshot(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store = (*Queries)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// InTx runs fn in a database transaction. Inside a transaction fn joins
+// it.
+func (q *Queries) InTx(ctx context.Context, fn func(Store) error) error {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can not start a transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InSnapshot runs fn in a read-only REPEATABLE READ transaction, so every
+// query of fn sees the same snapshot. SQLite ignores the options, but its
+// transactions read from a single snapshot anyway.
+func (q *Queries) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("can not start a transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
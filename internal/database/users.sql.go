@@ -12,22 +12,128 @@ import (
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRows = `-- name: CountRows :one
+SELECT (
+        SELECT COUNT(*)
+        FROM users
+    ) AS users,
+    (
+        SELECT COUNT(*)
+        FROM feeds
+    ) AS feeds,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow
+    ) AS follows,
+    (
+        SELECT COUNT(*)
+        FROM posts
+    ) AS posts
+`
+
+type CountRowsRow struct {
+	Users   int64
+	Feeds   int64
+	Follows int64
+	Posts   int64
+}
+
+func (q *Queries) CountRows(ctx context.Context) (CountRowsRow, error) {
+	row := q.db.QueryRowContext(ctx, countRows)
+	var i CountRowsRow
+	err := row.Scan(
+		&i.Users,
+		&i.Feeds,
+		&i.Follows,
+		&i.Posts,
+	)
+	return i, err
+}
+
+const countRowsForUser = `-- name: CountRowsForUser :one
+SELECT (
+        SELECT COUNT(*)
+        FROM feeds f
+        WHERE f.user_id = $1
+    ) AS feeds,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+        WHERE ff.user_id = $1
+    ) AS follows,
+    (
+        SELECT COUNT(*)
+        FROM posts p
+            INNER JOIN feeds f ON p.feed_id = f.id
+        WHERE f.user_id = $1
+    ) AS posts,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+            INNER JOIN feeds f ON ff.feed_id = f.id
+        WHERE f.user_id = $1
+            AND ff.user_id <> $1
+    ) AS other_follows
+`
+
+type CountRowsForUserRow struct {
+	Feeds        int64
+	Follows      int64
+	Posts        int64
+	OtherFollows int64
+}
+
+func (q *Queries) CountRowsForUser(ctx context.Context, userID uuid.NullUUID) (CountRowsForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, countRowsForUser, userID)
+	var i CountRowsForUserRow
+	err := row.Scan(
+		&i.Feeds,
+		&i.Follows,
+		&i.Posts,
+		&i.OtherFollows,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    -- The first user becomes an admin.
+    CASE
+        WHEN EXISTS (
+            SELECT 1
+            FROM users
+        ) THEN 'member'
+        ELSE 'admin'
+    END
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, fever_key, password_hash, role
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+	Name         string
+	PasswordHash sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +142,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.PasswordHash,
 	)
 	var i User
 	err := row.Scan(
@@ -43,6 +150,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.FeverKey,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
@@ -56,8 +166,18 @@ func (q *Queries) DeleteAllUser(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE name = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, name)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, fever_key, password_hash, role FROM users
 WHERE name = $1
 `
 
@@ -69,12 +189,15 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.FeverKey,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, fever_key, password_hash, role FROM users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -91,6 +214,9 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.FeverKey,
+			&i.PasswordHash,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -104,3 +230,58 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	}
 	return items, nil
 }
+
+const renameUser = `-- name: RenameUser :exec
+UPDATE users
+SET name = $1,
+    fever_key = NULL,
+    updated_at = $2
+WHERE name = $3
+`
+
+type RenameUserParams struct {
+	NewName   string
+	UpdatedAt sql.NullTime
+	OldName   string
+}
+
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	_, err := q.db.ExecContext(ctx, renameUser, arg.NewName, arg.UpdatedAt, arg.OldName)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET password_hash = $1,
+    updated_at = $2
+WHERE id = $3
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash sql.NullString
+	UpdatedAt    sql.NullTime
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.PasswordHash, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $1,
+    updated_at = $2
+WHERE id = $3
+`
+
+type SetUserRoleParams struct {
+	Role      string
+	UpdatedAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.UpdatedAt, arg.ID)
+	return err
+}
@@ -0,0 +1,354 @@
+// Package migrate applies the migrations embedded in gator. Applied
+// versions are kept in goose's goose_db_version table, so databases that
+// were migrated with goose carry on where they left off.
+package migrate
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	pgschema "github.com/babanini95/gatorcli/sql/schema"
+	sqliteschema "github.com/babanini95/gatorcli/sql/sqlite/schema"
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      []string
+	down    []string
+	noTx    bool
+}
+
+// Status tells whether a migration has been applied to the database.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt sql.NullTime
+}
+
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New returns a Migrator with the migrations for the database/sql driver,
+// postgres or sqlite3.
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	var fsys fs.FS
+	switch driver {
+	case "postgres":
+		fsys = pgschema.FS
+	case "sqlite3":
+		fsys = sqliteschema.FS
+	default:
+		return nil, fmt.Errorf("no migrations for %s databases", driver)
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the newest version applied to the database, 0 when it
+// has never been migrated.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns the ones it
+// applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.createVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.run(ctx, migration, migration.up,
+			"INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)")
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the newest applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if version == 0 {
+		return Migration{}, fmt.Errorf("no migrations to roll back")
+	}
+	i := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+	if i < 0 {
+		return Migration{}, fmt.Errorf("migration %d is not known to this version of gator", version)
+	}
+
+	migration := m.migrations[i]
+	err = m.run(ctx, migration, migration.down,
+		"DELETE FROM goose_db_version WHERE version_id = $1")
+	return migration, err
+}
+
+// run executes the statements of a migration and records it with the
+// version query, in one transaction unless the migration opts out.
+func (m *Migrator) run(ctx context.Context, migration Migration, statements []string, versionQuery string) error {
+	fail := func(err error) error {
+		return fmt.Errorf("migration %s failed: %v", migration.Name, err)
+	}
+
+	if migration.noTx {
+		for _, statement := range statements {
+			if _, err := m.db.ExecContext(ctx, statement); err != nil {
+				return fail(err)
+			}
+		}
+		if _, err := m.db.ExecContext(ctx, versionQuery, migration.Version); err != nil {
+			return fail(err)
+		}
+		return nil
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fail(err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, versionQuery, migration.Version); err != nil {
+		return fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// applied returns the applied versions with the time they were applied.
+func (m *Migrator) applied(ctx context.Context) (map[int64]sql.NullTime, error) {
+	applied := map[int64]sql.NullTime{}
+	exists, err := m.versionTableExists(ctx)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("can not read the schema version: %v", err)
+	}
+	defer rows.Close()
+
+	// Older goose versions record a rollback as a new row, so only the
+	// newest row of each version counts.
+	seen := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, fmt.Errorf("can not read the schema version: %v", err)
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied && version > 0 {
+			applied[version] = tstamp
+		}
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) versionTableExists(ctx context.Context) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM pg_tables WHERE schemaname = current_schema() AND tablename = 'goose_db_version')"
+	if m.driver == "sqlite3" {
+		query = "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')"
+	}
+
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return false, fmt.Errorf("can not read the schema version: %v", err)
+	}
+	return exists, nil
+}
+
+// createVersionTable creates goose_db_version the way goose does.
+func (m *Migrator) createVersionTable(ctx context.Context) error {
+	exists, err := m.versionTableExists(ctx)
+	if err != nil || exists {
+		return err
+	}
+
+	query := `CREATE TABLE goose_db_version (
+    id serial NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp NULL default now(),
+    PRIMARY KEY(id)
+)`
+	if m.driver == "sqlite3" {
+		query = `CREATE TABLE goose_db_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`
+	}
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("can not create goose_db_version: %v", err)
+	}
+	_, err = m.db.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true)")
+	if err != nil {
+		return fmt.Errorf("can not create goose_db_version: %v", err)
+	}
+	return nil
+}
+
+// load reads the goose migrations in fsys, ordered by version.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	for _, name := range names {
+		text, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := parse(name, string(text))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %v", name, err)
+		}
+		migrations = append(migrations, migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// parse splits a goose SQL migration into its up and down statements.
+// Statements end with a semicolon at the end of a line, unless they are
+// wrapped in StatementBegin and StatementEnd.
+func parse(name, text string) (Migration, error) {
+	prefix, _, ok := strings.Cut(path.Base(name), "_")
+	if !ok {
+		return Migration{}, fmt.Errorf("file name has no version")
+	}
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("file name has no version")
+	}
+	migration := Migration{Version: version, Name: name}
+
+	var section *[]string
+	var statement strings.Builder
+	inBlock := false
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch trimmed {
+		case "-- +goose Up":
+			section = &migration.up
+			continue
+		case "-- +goose Down":
+			section = &migration.down
+			continue
+		case "-- +goose NO TRANSACTION":
+			migration.noTx = true
+			continue
+		case "-- +goose StatementBegin":
+			inBlock = true
+			continue
+		case "-- +goose StatementEnd":
+			inBlock = false
+			if section != nil {
+				*section = append(*section, statement.String())
+			}
+			statement.Reset()
+			continue
+		}
+		if !inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if section == nil {
+			return Migration{}, fmt.Errorf("statement before -- +goose Up")
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			*section = append(*section, statement.String())
+			statement.Reset()
+		}
+	}
+	if inBlock || strings.TrimSpace(statement.String()) != "" {
+		return Migration{}, fmt.Errorf("unterminated statement")
+	}
+	return migration, scanner.Err()
+}
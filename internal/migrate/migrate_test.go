@@ -0,0 +1,377 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		text     string
+		wantErr  bool
+		wantUp   []string
+		wantDown []string
+		wantNoTx bool
+	}{
+		{
+			name: "up and down",
+			file: "001_users.sql",
+			text: `-- +goose Up
+CREATE TABLE users (
+    id UUID PRIMARY KEY
+);
+CREATE INDEX users_id ON users (id);
+
+-- +goose Down
+DROP TABLE users;
+`,
+			wantUp: []string{
+				"CREATE TABLE users (\n    id UUID PRIMARY KEY\n);\n",
+				"CREATE INDEX users_id ON users (id);\n",
+			},
+			wantDown: []string{"DROP TABLE users;\n"},
+		},
+		{
+			name: "comments are skipped",
+			file: "002_feeds.sql",
+			text: `-- a comment before the annotations
+-- +goose Up
+-- the feeds table
+CREATE TABLE feeds (id UUID);
+-- +goose Down
+DROP TABLE feeds;
+`,
+			wantUp:   []string{"CREATE TABLE feeds (id UUID);\n"},
+			wantDown: []string{"DROP TABLE feeds;\n"},
+		},
+		{
+			name: "statement block",
+			file: "003_notify.sql",
+			text: `-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION notify() RETURNS trigger AS $$
+BEGIN
+    -- not an annotation
+    PERFORM pg_notify('posts', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+CREATE TRIGGER posts_notify AFTER INSERT ON posts FOR EACH ROW EXECUTE FUNCTION notify();
+
+-- +goose Down
+DROP TRIGGER posts_notify ON posts;
+DROP FUNCTION notify;
+`,
+			wantUp: []string{
+				"CREATE FUNCTION notify() RETURNS trigger AS $$\nBEGIN\n    -- not an annotation\n    PERFORM pg_notify('posts', NEW.id::text);\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\n",
+				"CREATE TRIGGER posts_notify AFTER INSERT ON posts FOR EACH ROW EXECUTE FUNCTION notify();\n",
+			},
+			wantDown: []string{
+				"DROP TRIGGER posts_notify ON posts;\n",
+				"DROP FUNCTION notify;\n",
+			},
+		},
+		{
+			name: "no transaction",
+			file: "004_indexes.sql",
+			text: `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY posts_feed_id ON posts (feed_id);
+-- +goose Down
+DROP INDEX CONCURRENTLY posts_feed_id;
+`,
+			wantUp:   []string{"CREATE INDEX CONCURRENTLY posts_feed_id ON posts (feed_id);\n"},
+			wantDown: []string{"DROP INDEX CONCURRENTLY posts_feed_id;\n"},
+			wantNoTx: true,
+		},
+		{
+			name: "missing down",
+			file: "005_posts.sql",
+			text: `-- +goose Up
+ALTER TABLE posts ADD COLUMN read_at TIMESTAMP;
+`,
+			wantUp: []string{"ALTER TABLE posts ADD COLUMN read_at TIMESTAMP;\n"},
+		},
+		{
+			name:    "no version",
+			file:    "users.sql",
+			text:    "-- +goose Up\nSELECT 1;\n",
+			wantErr: true,
+		},
+		{
+			name:    "version zero",
+			file:    "000_users.sql",
+			text:    "-- +goose Up\nSELECT 1;\n",
+			wantErr: true,
+		},
+		{
+			name:    "statement before up",
+			file:    "006_posts.sql",
+			text:    "SELECT 1;\n-- +goose Up\nSELECT 2;\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing semicolon",
+			file:    "007_posts.sql",
+			text:    "-- +goose Up\nSELECT 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing statement end",
+			file:    "008_posts.sql",
+			text:    "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration, err := parse(tt.file, tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parse(%q) = %+v, want an error", tt.file, migration)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse(%q): %v", tt.file, err)
+			}
+			if migration.Name != tt.file {
+				t.Errorf("Name = %q, want %q", migration.Name, tt.file)
+			}
+			if !slices.Equal(migration.up, tt.wantUp) {
+				t.Errorf("up = %q, want %q", migration.up, tt.wantUp)
+			}
+			if !slices.Equal(migration.down, tt.wantDown) {
+				t.Errorf("down = %q, want %q", migration.down, tt.wantDown)
+			}
+			if migration.noTx != tt.wantNoTx {
+				t.Errorf("noTx = %v, want %v", migration.noTx, tt.wantNoTx)
+			}
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	migration, err := parse("sql/schema/013_indexes.sql", "-- +goose Up\nSELECT 1;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migration.Version != 13 {
+		t.Errorf("Version = %d, want 13", migration.Version)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, driver := range []string{"postgres", "sqlite3"} {
+		m, err := New(nil, driver)
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		for i, migration := range m.migrations {
+			if migration.Version != int64(i+1) {
+				t.Errorf("%s: migration %s has version %d, want %d", driver, migration.Name, migration.Version, i+1)
+			}
+			if len(migration.up) == 0 {
+				t.Errorf("%s: migration %s has no up statements", driver, migration.Name)
+			}
+			if len(migration.down) == 0 {
+				t.Errorf("%s: migration %s has no down statements", driver, migration.Name)
+			}
+		}
+	}
+
+	pg, _ := New(nil, "postgres")
+	sqlite, _ := New(nil, "sqlite3")
+	if pg.Latest() != sqlite.Latest() {
+		t.Errorf("postgres is at version %d, sqlite at %d", pg.Latest(), sqlite.Latest())
+	}
+}
+
+func TestUpDownSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gator.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := New(db, "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Fatalf("Version of a new database = %d, want 0", version)
+	}
+
+	done, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(m.migrations) {
+		t.Errorf("Up applied %d migrations, want %d", len(done), len(m.migrations))
+	}
+	if version, _ := m.Version(ctx); version != m.Latest() {
+		t.Errorf("Version after Up = %d, want %d", version, m.Latest())
+	}
+
+	done, err = m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 0 {
+		t.Errorf("second Up applied %d migrations, want 0", len(done))
+	}
+
+	rolledBack, err := m.Down(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack.Version != m.Latest() {
+		t.Errorf("Down rolled back %d, want %d", rolledBack.Version, m.Latest())
+	}
+	if version, _ := m.Version(ctx); version != m.Latest()-1 {
+		t.Errorf("Version after Down = %d, want %d", version, m.Latest()-1)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		want := status.Version < m.Latest()
+		if status.Applied != want {
+			t.Errorf("migration %s applied = %v, want %v", status.Name, status.Applied, want)
+		}
+	}
+
+	// Roll everything back and apply it again, so every down migration
+	// is exercised and leaves a schema the up migrations accept.
+	for {
+		version, err := m.Version(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version == 0 {
+			break
+		}
+		if _, err := m.Down(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Down(ctx); err == nil {
+		t.Error("Down on an empty database succeeded, want an error")
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLitePostSeq(t *testing.T) {
+	ctx := context.Background()
+	insertPost := func(db *sql.DB, id string) {
+		t.Helper()
+		if _, err := db.ExecContext(ctx, "INSERT INTO posts (id, url) VALUES (?, ?)", id, "https://example.com/"+id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkSeqs := func(db *sql.DB, want ...int64) {
+		t.Helper()
+		rows, err := db.QueryContext(ctx, "SELECT seq FROM posts ORDER BY rowid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		seqs := []int64{}
+		for rows.Next() {
+			var seq int64
+			if err := rows.Scan(&seq); err != nil {
+				t.Fatal(err)
+			}
+			seqs = append(seqs, seq)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(seqs, want) {
+			t.Errorf("posts have seq %v, want %v", seqs, want)
+		}
+	}
+
+	for _, existing := range []int{0, 2} {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gator.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		m, err := New(db, "sqlite3")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Posts saved before 008 keep their rowid, new ones follow them
+		// and the first post of a new database is 1, as on PostgreSQL.
+		before := &Migrator{db: db, driver: "sqlite3", migrations: m.migrations[:7]}
+		if _, err := before.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		want := []int64{}
+		for i := range existing {
+			insertPost(db, fmt.Sprintf("old%d", i))
+			want = append(want, int64(i+1))
+		}
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		insertPost(db, "new1")
+		insertPost(db, "new2")
+		checkSeqs(db, append(want, int64(existing+1), int64(existing+2))...)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	good, _ := parse("001_good.sql", "-- +goose Up\nCREATE TABLE good (id INTEGER);\n-- +goose Down\nDROP TABLE good;\n")
+	bad, _ := parse("002_bad.sql", "-- +goose Up\nCREATE TABLE half (id INTEGER);\nCREATE TABLE good (id INTEGER);\n-- +goose Down\nDROP TABLE half;\n")
+	m := &Migrator{db: db, driver: "sqlite3", migrations: []Migration{good, bad}}
+
+	done, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("Up succeeded, want an error from 002_bad.sql")
+	}
+	if len(done) != 1 || done[0].Version != 1 {
+		t.Errorf("Up applied %v, want only version 1", done)
+	}
+	if version, _ := m.Version(ctx); version != 1 {
+		t.Errorf("Version = %d, want 1", version)
+	}
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'half')").Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("table half exists, want the failed migration rolled back")
+	}
+}
@@ -1,33 +1,46 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/babanini95/gatorcli/internal/commands"
 	"github.com/babanini95/gatorcli/internal/config"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 	cfg, err := config.Read()
 	if err != nil {
-		fmt.Printf("%v", err)
-		return
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 
 	appState, err := commands.CreateNewState(cfg)
 	if err != nil {
-		fmt.Printf("error: %v", err)
-		return
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
 	appState.SaveConfig(cfg)
 	err = appState.CreateQueries()
 	if err != nil {
-		fmt.Printf("error: %v", err)
-		return
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
 
+	// Ctrl-C cancels the running command. Once it has, a second Ctrl-C
+	// kills gator right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	cmds := commands.InitCommands()
-	cmds.Run(appState, os.Args)
+	cmds.Run(ctx, appState, os.Args)
 }
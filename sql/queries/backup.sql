@@ -0,0 +1,125 @@
+-- name: GetPostByUrl :one
+SELECT *
+FROM posts
+WHERE url = $1;
+
+-- name: ListAllUsers :many
+SELECT *
+FROM users
+ORDER BY created_at, id;
+
+-- name: ListAllSessions :many
+SELECT *
+FROM sessions
+ORDER BY created_at, token_hash;
+
+-- name: ListAllFeeds :many
+SELECT *
+FROM feeds
+ORDER BY created_at, id;
+
+-- name: ListAllFeedRetention :many
+SELECT *
+FROM feed_retention
+ORDER BY feed_id;
+
+-- name: ListAllFeedFollows :many
+SELECT *
+FROM feeds_follow
+ORDER BY created_at, id;
+
+-- name: ListPostsAfter :many
+SELECT *
+FROM posts
+WHERE seq > $1
+ORDER BY seq
+LIMIT $2;
+
+-- name: ListAllPostStates :many
+SELECT *
+FROM posts_state
+ORDER BY user_id, post_id;
+
+-- name: RestoreUser :execrows
+INSERT INTO users (
+        id,
+        created_at,
+        updated_at,
+        name,
+        fever_key,
+        password_hash,
+        role
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreSession :execrows
+INSERT INTO sessions (
+        token_hash,
+        user_id,
+        created_at,
+        expires_at
+    )
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreFeed :execrows
+INSERT INTO feeds (
+        id,
+        created_at,
+        updated_at,
+        name,
+        url,
+        user_id,
+        last_fetched_at,
+        last_fetch_error
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreFeedRetention :execrows
+INSERT INTO feed_retention (
+        feed_id,
+        max_age,
+        max_posts,
+        keep_unread,
+        keep_starred
+    )
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreFeedFollow :execrows
+INSERT INTO feeds_follow (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        folder
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePost :execrows
+INSERT INTO posts (
+        id,
+        created_at,
+        updated_at,
+        title,
+        url,
+        description,
+        published_at,
+        feed_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePostState :execrows
+INSERT INTO posts_state (
+        user_id,
+        post_id,
+        read_at,
+        starred_at
+    )
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
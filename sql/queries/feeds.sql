@@ -11,17 +11,19 @@ FROM users
     RIGHT JOIN feeds ON users.id = feeds.user_id;
 
 -- name: CreateFeedFollow :one
-WITH inserted_feed_follow AS (
-    INSERT INTO feeds_follow (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING *
-)
-SELECT inserted_feed_follow.*,
-    feeds.name AS feed_name,
-    users.name AS user_name
-FROM inserted_feed_follow
-    INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id
-    INNER JOIN users ON inserted_feed_follow.user_id = users.id;
+INSERT INTO feeds_follow (id, created_at, updated_at, user_id, feed_id, folder)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *,
+    (
+        SELECT feeds.name
+        FROM feeds
+        WHERE feeds.id = feeds_follow.feed_id
+    ) AS feed_name,
+    (
+        SELECT users.name
+        FROM users
+        WHERE users.id = feeds_follow.user_id
+    ) AS user_name;
 
 -- name: GetFeedByUrl :one
 SELECT *
@@ -31,7 +33,8 @@ WHERE url = $1;
 -- name: GetFeedFollowsForUser :many
 SELECT feeds_follow.*,
     users.name AS user_name,
-    feeds.name AS feed_name
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
 FROM feeds_follow
     INNER JOIN users ON feeds_follow.user_id = users.id
     INNER JOIN feeds ON feeds_follow.feed_id = feeds.id
@@ -61,4 +64,97 @@ WHERE f.id IN (
         WHERE ff.user_id = $1
     )
 ORDER BY f.last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetNextFollowedFeedToFetch :one
+SELECT *
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST
+LIMIT 1;
+
+-- name: ListFeedsToFetch :many
+SELECT *
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+        WHERE ff.user_id = $1
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST;
+
+-- name: ListFollowedFeedsToFetch :many
+SELECT *
+FROM feeds f
+WHERE f.id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+    )
+ORDER BY f.last_fetched_at ASC NULLS FIRST;
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE feeds_follow
+SET folder = $1,
+    updated_at = $2
+WHERE feeds_follow.user_id = $3
+    AND feeds_follow.feed_id = (
+        SELECT id
+        FROM feeds
+        WHERE url = $4
+    );
+
+-- name: GetFoldersForUser :many
+SELECT DISTINCT folder
+FROM feeds_follow
+WHERE user_id = $1
+    AND folder IS NOT NULL
+ORDER BY folder;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: ResetFeedsFetched :exec
+UPDATE feeds
+SET last_fetched_at = NULL;
+
+-- name: GetFeedStats :one
+SELECT f.*,
+    u.name AS owner_name,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+        WHERE ff.feed_id = f.id
+    ) AS followers,
+    (
+        SELECT COUNT(*)
+        FROM posts p
+        WHERE p.feed_id = f.id
+    ) AS posts
+FROM feeds f
+    LEFT JOIN users u ON f.user_id = u.id
+WHERE f.url = $1;
+
+-- name: SetFeedFetchError :exec
+UPDATE feeds
+SET last_fetch_error = $1
+WHERE id = $2;
+
+-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $1,
+    updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $1,
+    last_fetch_error = NULL,
+    updated_at = $2
+WHERE id = $3;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
\ No newline at end of file
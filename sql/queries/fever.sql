@@ -0,0 +1,98 @@
+-- name: GetUserByFeverKey :one
+SELECT * FROM users
+WHERE fever_key = $1;
+
+-- name: SetUserFeverKey :exec
+UPDATE users
+SET fever_key = $1,
+    updated_at = $2
+WHERE id = $3;
+
+-- name: GetFeverFeedsForUser :many
+SELECT f.*,
+    ff.folder
+FROM feeds f
+    INNER JOIN feeds_follow ff ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+ORDER BY f.name;
+
+-- name: GetFeverItemsSince :many
+SELECT p.*,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND p.seq > $2
+ORDER BY p.seq ASC
+LIMIT $3;
+
+-- name: GetFeverItemsBefore :many
+SELECT p.*,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND p.seq < $2
+ORDER BY p.seq DESC
+LIMIT $3;
+
+-- name: GetFeverItem :one
+SELECT p.*,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND p.seq = $2;
+
+-- name: CountPostsForUser :one
+SELECT COUNT(*)
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = $1;
+
+-- name: GetUnreadPostSeqsForUser :many
+SELECT p.seq
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ps.read_at IS NULL
+ORDER BY p.seq;
+
+-- name: GetStarredPostSeqsForUser :many
+SELECT p.seq
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ps.starred_at IS NOT NULL
+ORDER BY p.seq;
+
+-- name: GetUnreadPostIDsBefore :many
+SELECT p.id
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+    AND ps.read_at IS NULL
+    AND (
+        sqlc.narg(feed_id) IS NULL
+        OR p.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(folder) IS NULL
+        OR ff.folder = sqlc.narg(folder)
+    )
+    AND p.created_at < sqlc.arg(before);
\ No newline at end of file
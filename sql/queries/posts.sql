@@ -20,4 +20,147 @@ WHERE p.feed_id IN (
         WHERE ff.user_id = $1
     )
 ORDER BY p.published_at DESC
-LIMIT $2;
\ No newline at end of file
+LIMIT $2;
+
+-- name: SearchPostsForUser :many
+SELECT *
+FROM posts p
+WHERE p.feed_id IN (
+        SELECT ff.feed_id
+        FROM feeds_follow ff
+        WHERE ff.user_id = sqlc.arg(user_id)
+    )
+    AND (
+        LOWER(p.title) LIKE LOWER(sqlc.arg(pattern)) ESCAPE '\'
+        OR LOWER(p.description) LIKE LOWER(sqlc.arg(pattern)) ESCAPE '\'
+    )
+ORDER BY p.published_at DESC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetPostsWithStateForUser :many
+SELECT p.*,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+ORDER BY p.published_at DESC
+LIMIT $2;
+
+-- name: GetPostsWithStateForFeed :many
+SELECT p.*,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND p.feed_id = $2
+ORDER BY p.published_at DESC
+LIMIT $3;
+
+-- name: GetPostsWithStateForFolder :many
+SELECT p.*,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ff.folder = $2
+ORDER BY p.published_at DESC
+LIMIT $3;
+
+-- name: GetUnreadCountsForUser :many
+SELECT p.feed_id,
+    COUNT(*) AS unread
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ps.read_at IS NULL
+GROUP BY p.feed_id;
+
+-- name: SetPostRead :exec
+INSERT INTO posts_state (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = excluded.read_at;
+
+-- name: SetPostStarred :exec
+INSERT INTO posts_state (user_id, post_id, starred_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred_at = excluded.starred_at;
+
+-- name: GetPostForUser :one
+SELECT p.*,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    ff.folder
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = $1
+    AND p.id = $2;
+
+-- name: GetPostsCreatedAfterForUser :many
+SELECT p.*,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    ff.folder
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+WHERE ff.user_id = $1
+    AND p.created_at > $2
+ORDER BY p.created_at ASC;
+
+-- name: ListPostsForUser :many
+SELECT p.*,
+    f.name AS feed_name,
+    ps.read_at,
+    ps.starred_at
+FROM posts p
+    INNER JOIN feeds_follow ff ON p.feed_id = ff.feed_id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    LEFT JOIN posts_state ps ON p.id = ps.post_id
+    AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(feed_id) IS NULL
+        OR p.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(folder) IS NULL
+        OR ff.folder = sqlc.narg(folder)
+    )
+    AND (
+        sqlc.narg(pattern) IS NULL
+        OR LOWER(p.title) LIKE LOWER(sqlc.narg(pattern)) ESCAPE '\'
+        OR LOWER(p.description) LIKE LOWER(sqlc.narg(pattern)) ESCAPE '\'
+    )
+    AND (
+        sqlc.narg(unread) IS NULL
+        OR (ps.read_at IS NULL) = sqlc.narg(unread)
+    )
+    AND (
+        sqlc.narg(starred) IS NULL
+        OR (ps.starred_at IS NOT NULL) = sqlc.narg(starred)
+    )
+ORDER BY p.published_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
\ No newline at end of file
@@ -0,0 +1,110 @@
+-- name: GetFeedRetention :one
+SELECT *
+FROM feed_retention
+WHERE feed_id = $1;
+
+-- name: SetFeedRetention :exec
+INSERT INTO feed_retention (
+        feed_id,
+        max_age,
+        max_posts,
+        keep_unread,
+        keep_starred
+    )
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id) DO UPDATE
+SET max_age = excluded.max_age,
+    max_posts = excluded.max_posts,
+    keep_unread = excluded.keep_unread,
+    keep_starred = excluded.keep_starred;
+
+-- name: DeleteFeedRetention :exec
+DELETE FROM feed_retention
+WHERE feed_id = $1;
+
+-- name: ListFeedsWithRetention :many
+SELECT f.id,
+    f.name,
+    f.url,
+    r.max_age,
+    r.max_posts,
+    r.keep_unread,
+    r.keep_starred
+FROM feeds f
+    LEFT JOIN feed_retention r ON f.id = r.feed_id
+ORDER BY f.name;
+
+-- name: CountPrunablePosts :one
+SELECT COUNT(*)
+FROM (
+        SELECT p.id,
+            COALESCE(p.published_at, p.created_at) AS posted_at,
+            ROW_NUMBER() OVER (
+                ORDER BY COALESCE(p.published_at, p.created_at) DESC
+            ) AS position
+        FROM posts p
+        WHERE p.feed_id = sqlc.arg(feed_id)
+    ) ranked
+WHERE (
+        ranked.posted_at < sqlc.narg(before)
+        OR ranked.position > sqlc.narg(max_posts)
+    )
+    AND NOT (
+        sqlc.arg(keep_unread)
+        AND EXISTS (
+            SELECT 1
+            FROM feeds_follow ff
+                LEFT JOIN posts_state ps ON ps.post_id = ranked.id
+                AND ps.user_id = ff.user_id
+            WHERE ff.feed_id = sqlc.arg(feed_id)
+                AND ps.read_at IS NULL
+        )
+    )
+    AND NOT (
+        sqlc.arg(keep_starred)
+        AND EXISTS (
+            SELECT 1
+            FROM posts_state ps
+            WHERE ps.post_id = ranked.id
+                AND ps.starred_at IS NOT NULL
+        )
+    );
+
+-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE id IN (
+        SELECT ranked.id
+        FROM (
+                SELECT p.id,
+                    COALESCE(p.published_at, p.created_at) AS posted_at,
+                    ROW_NUMBER() OVER (
+                        ORDER BY COALESCE(p.published_at, p.created_at) DESC
+                    ) AS position
+                FROM posts p
+                WHERE p.feed_id = sqlc.arg(feed_id)
+            ) ranked
+        WHERE (
+                ranked.posted_at < sqlc.narg(before)
+                OR ranked.position > sqlc.narg(max_posts)
+            )
+            AND NOT (
+                sqlc.arg(keep_unread)
+                AND EXISTS (
+                    SELECT 1
+                    FROM feeds_follow ff
+                        LEFT JOIN posts_state ps ON ps.post_id = ranked.id
+                        AND ps.user_id = ff.user_id
+                    WHERE ff.feed_id = sqlc.arg(feed_id)
+                        AND ps.read_at IS NULL
+                )
+            )
+            AND NOT (
+                sqlc.arg(keep_starred)
+                AND EXISTS (
+                    SELECT 1
+                    FROM posts_state ps
+                    WHERE ps.post_id = ranked.id
+                        AND ps.starred_at IS NOT NULL
+                )
+            )
+    );
\ No newline at end of file
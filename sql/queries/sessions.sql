@@ -0,0 +1,22 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetSessionUser :one
+SELECT u.* FROM users u
+    INNER JOIN sessions s ON u.id = s.user_id
+WHERE s.token_hash = $1
+    AND s.expires_at > $2;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions
+WHERE user_id = $1;
\ No newline at end of file
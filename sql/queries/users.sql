@@ -1,10 +1,19 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    -- The first user becomes an admin.
+    CASE
+        WHEN EXISTS (
+            SELECT 1
+            FROM users
+        ) THEN 'member'
+        ELSE 'admin'
+    END
 )
 RETURNING *;
 
@@ -16,4 +25,75 @@ WHERE name = $1;
 DELETE FROM users;
 
 -- name: GetUsers :many
-SELECT * FROM users;
\ No newline at end of file
+SELECT * FROM users;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET password_hash = $1,
+    updated_at = $2
+WHERE id = $3;
+
+-- name: CountRows :one
+SELECT (
+        SELECT COUNT(*)
+        FROM users
+    ) AS users,
+    (
+        SELECT COUNT(*)
+        FROM feeds
+    ) AS feeds,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow
+    ) AS follows,
+    (
+        SELECT COUNT(*)
+        FROM posts
+    ) AS posts;
+
+-- name: CountRowsForUser :one
+SELECT (
+        SELECT COUNT(*)
+        FROM feeds f
+        WHERE f.user_id = $1
+    ) AS feeds,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+        WHERE ff.user_id = $1
+    ) AS follows,
+    (
+        SELECT COUNT(*)
+        FROM posts p
+            INNER JOIN feeds f ON p.feed_id = f.id
+        WHERE f.user_id = $1
+    ) AS posts,
+    (
+        SELECT COUNT(*)
+        FROM feeds_follow ff
+            INNER JOIN feeds f ON ff.feed_id = f.id
+        WHERE f.user_id = $1
+            AND ff.user_id <> $1
+    ) AS other_follows;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE name = $1;
+
+-- name: RenameUser :exec
+UPDATE users
+SET name = sqlc.arg(new_name),
+    fever_key = NULL,
+    updated_at = sqlc.arg(updated_at)
+WHERE name = sqlc.arg(old_name);
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $1,
+    updated_at = $2
+WHERE id = $3;
+
+-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE role = 'admin';
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION notify_new_post() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('gator_new_post', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER posts_notify_insert
+AFTER INSERT ON posts
+FOR EACH ROW EXECUTE FUNCTION notify_new_post();
+
+-- +goose Down
+DROP TRIGGER posts_notify_insert ON posts;
+DROP FUNCTION notify_new_post();
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN fever_key TEXT UNIQUE;
+
+ALTER TABLE posts
+ADD COLUMN seq BIGSERIAL;
+
+CREATE UNIQUE INDEX posts_seq_idx ON posts (seq);
+
+-- +goose Down
+DROP INDEX posts_seq_idx;
+ALTER TABLE posts DROP COLUMN seq;
+ALTER TABLE users DROP COLUMN fever_key;
\ No newline at end of file
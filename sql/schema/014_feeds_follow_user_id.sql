@@ -0,0 +1,9 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Every listing of a user's posts and feeds starts from their follows. The
+-- UNIQUE (user_id, feed_id) index can serve these lookups too, but this one
+-- is half its size and keeps them fast if that constraint ever changes.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS feeds_follow_user_id_idx ON feeds_follow (user_id);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS feeds_follow_user_id_idx;
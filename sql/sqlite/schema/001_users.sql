@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    name TEXT UNIQUE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
\ No newline at end of file
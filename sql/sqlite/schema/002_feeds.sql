@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE feeds (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    name TEXT,
+    url TEXT UNIQUE,
+    user_id TEXT,
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feeds;
\ No newline at end of file
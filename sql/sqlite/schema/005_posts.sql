@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE posts(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    title TEXT,
+    url TEXT UNIQUE,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_id TEXT,
+    FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE posts;
\ No newline at end of file
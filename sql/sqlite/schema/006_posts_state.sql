@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE feeds_follow
+ADD COLUMN folder TEXT;
+
+CREATE TABLE posts_state (
+    user_id TEXT NOT NULL,
+    post_id TEXT NOT NULL,
+    read_at TIMESTAMP,
+    starred_at TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE posts_state;
+ALTER TABLE feeds_follow DROP COLUMN folder;
\ No newline at end of file
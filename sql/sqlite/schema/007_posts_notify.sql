@@ -0,0 +1,7 @@
+-- +goose Up
+-- SQLite has no LISTEN/NOTIFY, gator watch polls for new posts instead.
+-- This migration keeps the versions in step with the PostgreSQL schema.
+SELECT 1;
+
+-- +goose Down
+SELECT 1;
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN fever_key TEXT;
+
+CREATE UNIQUE INDEX users_fever_key_idx ON users (fever_key);
+
+ALTER TABLE posts
+ADD COLUMN seq INTEGER;
+
+CREATE UNIQUE INDEX posts_seq_idx ON posts (seq);
+
+-- Fever item ids must never be reused, so seq comes from an AUTOINCREMENT
+-- counter rather than the rowid.
+CREATE TABLE posts_seq (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT
+);
+
+-- The counter carries on after the existing posts. Without posts MAX would
+-- insert NULL and use up 1, so items would start at 2 rather than 1 as on
+-- PostgreSQL.
+UPDATE posts SET seq = rowid;
+INSERT INTO posts_seq (seq) SELECT MAX(seq) FROM posts HAVING COUNT(*) > 0;
+DELETE FROM posts_seq;
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_seq_insert
+AFTER INSERT ON posts
+FOR EACH ROW WHEN NEW.seq IS NULL
+BEGIN
+    INSERT INTO posts_seq (seq) VALUES (NULL);
+    UPDATE posts SET seq = last_insert_rowid() WHERE rowid = NEW.rowid;
+    DELETE FROM posts_seq;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER posts_seq_insert;
+DROP TABLE posts_seq;
+DROP INDEX posts_seq_idx;
+ALTER TABLE posts DROP COLUMN seq;
+DROP INDEX users_fever_key_idx;
+ALTER TABLE users DROP COLUMN fever_key;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member'));
+
+-- The first user becomes an admin.
+UPDATE users
+SET role = 'admin'
+WHERE id = (
+        SELECT id
+        FROM users
+        ORDER BY created_at ASC
+        LIMIT 1
+    );
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Per-feed overrides of the retention policy in the config. NULL keeps the
+-- global setting. max_age is in seconds, 0 disables a limit.
+CREATE TABLE feed_retention (
+    feed_id TEXT PRIMARY KEY,
+    max_age BIGINT,
+    max_posts INTEGER,
+    keep_unread BOOLEAN,
+    keep_starred BOOLEAN,
+    FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_retention;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Indexes for listing the posts of followed feeds newest first and for
+-- picking the feed to fetch next. SQLite sorts NULL first, so the
+-- last_fetched_at index matches ORDER BY last_fetched_at ASC NULLS FIRST.
+-- Lookups of feeds_follow by feed_id get their own index, see 014 for
+-- user_id.
+CREATE INDEX IF NOT EXISTS posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC);
+CREATE INDEX IF NOT EXISTS feeds_last_fetched_at_idx ON feeds (last_fetched_at);
+CREATE INDEX IF NOT EXISTS feeds_follow_feed_id_idx ON feeds_follow (feed_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS feeds_follow_feed_id_idx;
+DROP INDEX IF EXISTS feeds_last_fetched_at_idx;
+DROP INDEX IF EXISTS posts_feed_id_published_at_idx;
\ No newline at end of file
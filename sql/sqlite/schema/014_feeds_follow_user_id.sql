@@ -0,0 +1,8 @@
+-- +goose Up
+-- Every listing of a user's posts and feeds starts from their follows. The
+-- UNIQUE (user_id, feed_id) index can serve these lookups too, but this one
+-- is half its size and keeps them fast if that constraint ever changes.
+CREATE INDEX IF NOT EXISTS feeds_follow_user_id_idx ON feeds_follow (user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS feeds_follow_user_id_idx;
@@ -0,0 +1,8 @@
+// Package schema embeds the SQLite migrations, so gator can migrate the
+// database without goose.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS